@@ -18,8 +18,15 @@ import (
 type ocsCephNetworkFilesystem struct{}
 
 // newcephNetworkFilesystemInstance returns the cephNetworkFilesystem instances that should be created
-// on first run.
-func (r *StorageClusterReconciler) newCephNetworkFilesystemInstance(initData *ocsv1.StorageCluster) (*cephv1.CephNFS, error) {
+// on first run. The Ganesha export ConfigMap rook renders for this CephNFS
+// is hashed and stamped on the Server pod template annotations so that any
+// change to the exports triggers a rolling restart of the Ganesha pods.
+func (r *StorageClusterReconciler) newCephNetworkFilesystemInstance(ctx context.Context, initData *ocsv1.StorageCluster) (*cephv1.CephNFS, error) {
+	configHash, err := getNFSConfigMapHash(r, ctx, generateNameForCephNetworkFilesystem(initData), initData.Namespace)
+	if err != nil {
+		return nil, err
+	}
+
 	obj := &cephv1.CephNFS{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      generateNameForCephNetworkFilesystem(initData),
@@ -32,11 +39,14 @@ func (r *StorageClusterReconciler) newCephNetworkFilesystemInstance(initData *oc
 				Resources: defaults.GetDaemonResources("nfs", initData.Spec.Resources),
 				// set PriorityClassName for the NFS pods
 				PriorityClassName: openshiftUserCritical,
+				Annotations: map[string]string{
+					nfsConfigHashAnnotation: configHash,
+				},
 			},
 		},
 	}
 
-	err := controllerutil.SetControllerReference(initData, obj, r.Scheme)
+	err = controllerutil.SetControllerReference(initData, obj, r.Scheme)
 	if err != nil {
 		r.Log.Error(err, "Unable to set Controller Reference for cephNetworkFilesystem.", "cephNetworkFilesystem", klog.KRef(obj.Namespace, obj.Name))
 		return nil, err
@@ -47,18 +57,22 @@ func (r *StorageClusterReconciler) newCephNetworkFilesystemInstance(initData *oc
 
 // ensureCreated ensures that cephNetworkFilesystem resources exist in the desired
 // state.
-func (obj *ocsCephNetworkFilesystem) ensureCreated(r *StorageClusterReconciler, instance *ocsv1.StorageCluster) (reconcile.Result, error) {
-	if instance.Spec.NFS == nil || !instance.Spec.NFS.Enable {
-		return obj.ensureDeleted(r, instance)
+func (obj *ocsCephNetworkFilesystem) ensureCreated(r *StorageClusterReconciler, ctx context.Context, instance *ocsv1.StorageCluster) (reconcile.Result, error) {
+	if err := ctx.Err(); err != nil {
+		return reconcile.Result{}, err
+	}
+
+	if instance.Spec.NFS == nil || !instance.Spec.NFS.Enable || isClusterBeingCleanedUp(instance) {
+		return obj.ensureDeleted(r, ctx, instance)
 	}
 
-	cephNetworkFilesystem, err := r.newCephNetworkFilesystemInstance(instance)
+	cephNetworkFilesystem, err := r.newCephNetworkFilesystemInstance(ctx, instance)
 	if err != nil {
 		return reconcile.Result{}, err
 	}
 
 	existing := cephv1.CephNFS{}
-	err = r.Client.Get(context.TODO(), types.NamespacedName{Name: cephNetworkFilesystem.Name, Namespace: cephNetworkFilesystem.Namespace}, &existing)
+	err = r.Client.Get(ctx, types.NamespacedName{Name: cephNetworkFilesystem.Name, Namespace: cephNetworkFilesystem.Namespace}, &existing)
 	switch {
 	case err == nil:
 		if existing.DeletionTimestamp != nil {
@@ -66,17 +80,31 @@ func (obj *ocsCephNetworkFilesystem) ensureCreated(r *StorageClusterReconciler,
 			return reconcile.Result{}, fmt.Errorf("failed to restore initialization object %s because it is marked for deletion", existing.Name)
 		}
 
-		r.Log.Info("Restoring original cephNetworkFilesystem.", "cephNetworkFilesystem", klog.KRef(cephNetworkFilesystem.Namespace, cephNetworkFilesystem.Name))
 		existing.ObjectMeta.OwnerReferences = cephNetworkFilesystem.ObjectMeta.OwnerReferences
-		existing.Spec = cephNetworkFilesystem.Spec
-		err = r.Client.Update(context.TODO(), &existing)
+
+		// Reconcile only the fields OCS owns (mirroring ocsCephNFS's
+		// mergeCephNFSOwnedFields), so admin-set extras such as
+		// Server.LogLevel or Server.HostNetwork survive reconciles instead
+		// of being stomped by a blanket spec overwrite.
+		if mergeCephNFSOwnedFields(instance, &existing.Spec, cephNetworkFilesystem.Spec) {
+			r.Log.Info("Restoring drifted fields on cephNetworkFilesystem.", "cephNetworkFilesystem", klog.KRef(cephNetworkFilesystem.Namespace, cephNetworkFilesystem.Name))
+		}
+		if existing.Spec.Server.Annotations[nfsConfigHashAnnotation] != cephNetworkFilesystem.Spec.Server.Annotations[nfsConfigHashAnnotation] {
+			r.Log.Info("NFS export configuration changed, restarting Ganesha servers.", "cephNetworkFilesystem", klog.KRef(cephNetworkFilesystem.Namespace, cephNetworkFilesystem.Name))
+			if existing.Spec.Server.Annotations == nil {
+				existing.Spec.Server.Annotations = map[string]string{}
+			}
+			existing.Spec.Server.Annotations[nfsConfigHashAnnotation] = cephNetworkFilesystem.Spec.Server.Annotations[nfsConfigHashAnnotation]
+		}
+
+		err = r.Client.Update(ctx, &existing)
 		if err != nil {
 			r.Log.Error(err, "Unable to update cephNetworkFilesystem.", "cephNetworkFilesystem", klog.KRef(cephNetworkFilesystem.Namespace, cephNetworkFilesystem.Name))
 			return reconcile.Result{}, err
 		}
 	case errors.IsNotFound(err):
 		r.Log.Info("Creating cephNetworkFilesystem.", "cephNetworkFilesystem", klog.KRef(cephNetworkFilesystem.Namespace, cephNetworkFilesystem.Name))
-		err = r.Client.Create(context.TODO(), cephNetworkFilesystem)
+		err = r.Client.Create(ctx, cephNetworkFilesystem)
 		if err != nil {
 			r.Log.Error(err, "Unable to create cephNetworkFilesystem.", "cephNetworkFilesystem", klog.KRef(cephNetworkFilesystem.Namespace, cephNetworkFilesystem.Name))
 			return reconcile.Result{}, err
@@ -87,14 +115,14 @@ func (obj *ocsCephNetworkFilesystem) ensureCreated(r *StorageClusterReconciler,
 }
 
 // ensureDeleted deletes the CephNetworkNetworkFilesystems owned by the StorageCluster
-func (obj *ocsCephNetworkFilesystem) ensureDeleted(r *StorageClusterReconciler, sc *ocsv1.StorageCluster) (reconcile.Result, error) {
+func (obj *ocsCephNetworkFilesystem) ensureDeleted(r *StorageClusterReconciler, ctx context.Context, sc *ocsv1.StorageCluster) (reconcile.Result, error) {
 	foundCephNetworkFilesystem := &cephv1.CephNFS{}
-	cephNetworkFilesystem, err := r.newCephNetworkFilesystemInstance(sc)
+	cephNetworkFilesystem, err := r.newCephNetworkFilesystemInstance(ctx, sc)
 	if err != nil {
 		return reconcile.Result{}, err
 	}
 
-	err = r.Client.Get(context.TODO(), types.NamespacedName{Name: cephNetworkFilesystem.Name, Namespace: sc.Namespace}, foundCephNetworkFilesystem)
+	err = r.Client.Get(ctx, types.NamespacedName{Name: cephNetworkFilesystem.Name, Namespace: sc.Namespace}, foundCephNetworkFilesystem)
 	if err != nil {
 		if errors.IsNotFound(err) {
 			r.Log.Info("Uninstall: CephNetworkFileSystem not found.", "CephNetworkFileSystem", klog.KRef(cephNetworkFilesystem.Namespace, cephNetworkFilesystem.Name))
@@ -106,14 +134,18 @@ func (obj *ocsCephNetworkFilesystem) ensureDeleted(r *StorageClusterReconciler,
 
 	if cephNetworkFilesystem.GetDeletionTimestamp().IsZero() {
 		r.Log.Info("Uninstall: Deleting cephNetworkFilesystem.", "CephNetworkFileSystem", klog.KRef(foundCephNetworkFilesystem.Namespace, foundCephNetworkFilesystem.Name))
-		err = r.Client.Delete(context.TODO(), foundCephNetworkFilesystem)
+		err = r.Client.Delete(ctx, foundCephNetworkFilesystem)
 		if err != nil {
 			r.Log.Error(err, "Uninstall: Failed to delete cephNetworkFilesystem.", "cephNetworkFilesystem", klog.KRef(foundCephNetworkFilesystem.Namespace, foundCephNetworkFilesystem.Name))
 			return reconcile.Result{}, fmt.Errorf("uninstall: Failed to delete cephNetworkFilesystem %v: %v", foundCephNetworkFilesystem.Name, err)
 		}
 	}
 
-	err = r.Client.Get(context.TODO(), types.NamespacedName{Name: cephNetworkFilesystem.Name, Namespace: sc.Namespace}, foundCephNetworkFilesystem)
+	if isClusterBeingCleanedUp(sc) {
+		return reconcile.Result{}, nil
+	}
+
+	err = r.Client.Get(ctx, types.NamespacedName{Name: cephNetworkFilesystem.Name, Namespace: sc.Namespace}, foundCephNetworkFilesystem)
 	if err != nil {
 		if errors.IsNotFound(err) {
 			r.Log.Info("Uninstall: cephNetworkFilesystem is deleted.", "cephNetworkFilesystem", klog.KRef(cephNetworkFilesystem.Namespace, cephNetworkFilesystem.Name))
@@ -124,3 +156,9 @@ func (obj *ocsCephNetworkFilesystem) ensureDeleted(r *StorageClusterReconciler,
 	r.Log.Error(err, "Uninstall: Waiting for cephNetworkFilesystem to be deleted.", "cephNetworkFilesystem", klog.KRef(cephNetworkFilesystem.Namespace, cephNetworkFilesystem.Name))
 	return reconcile.Result{}, fmt.Errorf("uninstall: Waiting for cephNetworkFilesystem %v to be deleted", cephNetworkFilesystem.Name)
 }
+
+// generateNameForCephNetworkFilesystem returns the name of the CephNFS CR
+// this StorageCluster owns.
+func generateNameForCephNetworkFilesystem(initData *ocsv1.StorageCluster) string {
+	return fmt.Sprintf("%s-cephnetworkfilesystem", initData.Name)
+}
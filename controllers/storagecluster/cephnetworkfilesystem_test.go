@@ -54,10 +54,10 @@ func assertCephNetworkFileSystem(t *testing.T, reconciler StorageClusterReconcil
 		},
 	}
 	request.Name = "ocsinit-cephnetworkfilesystem"
-	err := reconciler.Client.Get(context.TODO(), request.NamespacedName, actualNfs)
+	err := reconciler.Client.Get(context.Background(), request.NamespacedName, actualNfs)
 	assert.NoError(t, err)
 
-	expectedAf, err := reconciler.newCephNetworkFilesystemInstance(cr)
+	expectedAf, err := reconciler.newCephNetworkFilesystemInstance(context.Background(), cr)
 	assert.NoError(t, err)
 
 	assert.Equal(t, len(expectedAf.OwnerReferences), 1)
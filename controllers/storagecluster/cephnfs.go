@@ -28,7 +28,7 @@ func (r *StorageClusterReconciler) newCephNFSInstance(initData *ocsv1.StorageClu
 		},
 		Spec: cephv1.NFSGaneshaSpec{
 			Server: cephv1.GaneshaServerSpec{
-				Active:    1,
+				Active:    getNFSActiveServerCount(initData),
 				Placement: getPlacement(initData, "nfs"),
 				Resources: defaults.GetDaemonResources("nfs", initData.Spec.Resources),
 				// set PriorityClassName for the NFS pods
@@ -45,9 +45,13 @@ func (r *StorageClusterReconciler) newCephNFSInstance(initData *ocsv1.StorageClu
 }
 
 // ensureCreated ensures that cephNFS resource exist in the desired state.
-func (obj *ocsCephNFS) ensureCreated(r *StorageClusterReconciler, instance *ocsv1.StorageCluster) (reconcile.Result, error) {
-	if instance.Spec.NFS == nil || !instance.Spec.NFS.Enable {
-		return obj.ensureDeleted(r, instance)
+func (obj *ocsCephNFS) ensureCreated(r *StorageClusterReconciler, ctx context.Context, instance *ocsv1.StorageCluster) (reconcile.Result, error) {
+	if err := ctx.Err(); err != nil {
+		return reconcile.Result{}, err
+	}
+
+	if instance.Spec.NFS == nil || !instance.Spec.NFS.Enable || isClusterBeingCleanedUp(instance) {
+		return obj.ensureDeleted(r, ctx, instance)
 	}
 
 	cephNFS, err := r.newCephNFSInstance(instance)
@@ -55,7 +59,7 @@ func (obj *ocsCephNFS) ensureCreated(r *StorageClusterReconciler, instance *ocsv
 		return reconcile.Result{}, err
 	}
 	existingCephNFS := cephv1.CephNFS{}
-	err = r.Client.Get(context.TODO(), types.NamespacedName{Name: cephNFS.Name, Namespace: cephNFS.Namespace}, &existingCephNFS)
+	err = r.Client.Get(ctx, types.NamespacedName{Name: cephNFS.Name, Namespace: cephNFS.Namespace}, &existingCephNFS)
 	switch {
 	case err == nil:
 		if existingCephNFS.DeletionTimestamp != nil {
@@ -63,17 +67,19 @@ func (obj *ocsCephNFS) ensureCreated(r *StorageClusterReconciler, instance *ocsv
 			return reconcile.Result{}, fmt.Errorf("failed to restore initialization object %s because it is marked for deletion", existingCephNFS.Name)
 		}
 
-		r.Log.Info("Restoring original CephNFS.", "CephNFS", klog.KRef(cephNFS.Namespace, cephNFS.Name))
 		existingCephNFS.ObjectMeta.OwnerReferences = cephNFS.ObjectMeta.OwnerReferences
-		existingCephNFS.Spec = cephNFS.Spec
-		err = r.Client.Update(context.TODO(), &existingCephNFS)
+		if mergeCephNFSOwnedFields(instance, &existingCephNFS.Spec, cephNFS.Spec) {
+			r.Log.Info("Restoring drifted fields on CephNFS.", "CephNFS", klog.KRef(cephNFS.Namespace, cephNFS.Name))
+			r.recorder.Event(instance, v1.EventTypeNormal, "CephNFSDriftCorrected", fmt.Sprintf("Reconciled OCS-owned fields on CephNFS %s", cephNFS.Name))
+		}
+		err = r.Client.Update(ctx, &existingCephNFS)
 		if err != nil {
 			r.Log.Error(err, "Unable to update CephNFS.", "CephNFS", klog.KRef(cephNFS.Namespace, cephNFS.Name))
 			return reconcile.Result{}, err
 		}
 	case errors.IsNotFound(err):
 		r.Log.Info("Creating CephNFS.", "CephNFS", klog.KRef(cephNFS.Namespace, cephNFS.Name))
-		err = r.Client.Create(context.TODO(), cephNFS)
+		err = r.Client.Create(ctx, cephNFS)
 		if err != nil {
 			r.Log.Error(err, "Unable to create CephNFS.", "CephNFS", klog.KRef(cephNFS.Namespace, cephNFS.Name))
 			return reconcile.Result{}, err
@@ -84,14 +90,14 @@ func (obj *ocsCephNFS) ensureCreated(r *StorageClusterReconciler, instance *ocsv
 }
 
 // ensureDeleted deletes the CephNFS resource owned by the StorageCluster
-func (obj *ocsCephNFS) ensureDeleted(r *StorageClusterReconciler, sc *ocsv1.StorageCluster) (reconcile.Result, error) {
+func (obj *ocsCephNFS) ensureDeleted(r *StorageClusterReconciler, ctx context.Context, sc *ocsv1.StorageCluster) (reconcile.Result, error) {
 	foundCephNFS := &cephv1.CephNFS{}
 	cephNFS, err := r.newCephNFSInstance(sc)
 	if err != nil {
 		return reconcile.Result{}, err
 	}
 
-	err = r.Client.Get(context.TODO(), types.NamespacedName{Name: cephNFS.Name, Namespace: sc.Namespace}, foundCephNFS)
+	err = r.Client.Get(ctx, types.NamespacedName{Name: cephNFS.Name, Namespace: sc.Namespace}, foundCephNFS)
 	if err != nil {
 		if errors.IsNotFound(err) {
 			r.Log.Info("Uninstall: CephNFS not found.", "CephNFS", klog.KRef(cephNFS.Namespace, cephNFS.Name))
@@ -103,14 +109,20 @@ func (obj *ocsCephNFS) ensureDeleted(r *StorageClusterReconciler, sc *ocsv1.Stor
 
 	if cephNFS.GetDeletionTimestamp().IsZero() {
 		r.Log.Info("Uninstall: Deleting CephNFS.", "CephNFS", klog.KRef(foundCephNFS.Namespace, foundCephNFS.Name))
-		err = r.Client.Delete(context.TODO(), foundCephNFS)
+		err = r.Client.Delete(ctx, foundCephNFS)
 		if err != nil {
 			r.Log.Error(err, "Uninstall: Failed to delete CephNFS.", "CephNFS", klog.KRef(foundCephNFS.Namespace, foundCephNFS.Name))
 			return reconcile.Result{}, fmt.Errorf("uninstall: Failed to delete CephNFS %v: %v", foundCephNFS.Name, err)
 		}
 	}
 
-	err = r.Client.Get(context.TODO(), types.NamespacedName{Name: cephNFS.Name, Namespace: sc.Namespace}, foundCephNFS)
+	if isClusterBeingCleanedUp(sc) {
+		// Forced destroy: don't block tear-down waiting for Ceph to
+		// acknowledge the delete, since Ceph may never become responsive.
+		return reconcile.Result{}, nil
+	}
+
+	err = r.Client.Get(ctx, types.NamespacedName{Name: cephNFS.Name, Namespace: sc.Namespace}, foundCephNFS)
 	if err != nil {
 		if errors.IsNotFound(err) {
 			r.Log.Info("Uninstall: CephNFS is deleted.", "CephNFS", klog.KRef(cephNFS.Namespace, cephNFS.Name))
@@ -132,7 +144,7 @@ func (r *StorageClusterReconciler) newCephNFSBlockPoolInstance(initData *ocsv1.S
 			Namespace: initData.Namespace,
 		},
 		Spec: cephv1.NamedBlockPoolSpec{
-			Name: ".nfs",
+			Name: nfsCSIPoolName,
 			PoolSpec: cephv1.PoolSpec{
 				FailureDomain:  getFailureDomain(initData),
 				Replicated:     generateCephReplicatedSpec(initData, "data"),
@@ -151,9 +163,13 @@ func (r *StorageClusterReconciler) newCephNFSBlockPoolInstance(initData *ocsv1.S
 }
 
 // ensureCreated ensures that CephNFS related CephBlockPool resource exist in the desired state.
-func (obj *ocsCephNFSBlockPool) ensureCreated(r *StorageClusterReconciler, instance *ocsv1.StorageCluster) (reconcile.Result, error) {
-	if instance.Spec.NFS == nil || !instance.Spec.NFS.Enable {
-		return obj.ensureDeleted(r, instance)
+func (obj *ocsCephNFSBlockPool) ensureCreated(r *StorageClusterReconciler, ctx context.Context, instance *ocsv1.StorageCluster) (reconcile.Result, error) {
+	if err := ctx.Err(); err != nil {
+		return reconcile.Result{}, err
+	}
+
+	if instance.Spec.NFS == nil || !instance.Spec.NFS.Enable || isClusterBeingCleanedUp(instance) {
+		return obj.ensureDeleted(r, ctx, instance)
 	}
 
 	cephBlockPool, err := r.newCephNFSBlockPoolInstance(instance)
@@ -161,7 +177,7 @@ func (obj *ocsCephNFSBlockPool) ensureCreated(r *StorageClusterReconciler, insta
 		return reconcile.Result{}, err
 	}
 	existingBlockPool := cephv1.CephBlockPool{}
-	err = r.Client.Get(context.TODO(), types.NamespacedName{Name: cephBlockPool.Name, Namespace: cephBlockPool.Namespace}, &existingBlockPool)
+	err = r.Client.Get(ctx, types.NamespacedName{Name: cephBlockPool.Name, Namespace: cephBlockPool.Namespace}, &existingBlockPool)
 
 	switch {
 	case err == nil:
@@ -170,17 +186,19 @@ func (obj *ocsCephNFSBlockPool) ensureCreated(r *StorageClusterReconciler, insta
 			return reconcile.Result{}, fmt.Errorf("failed to restore initialization object %s because it is marked for deletion", existingBlockPool.Name)
 		}
 
-		r.Log.Info("Restoring original CephBlockPool.", "CephBlockPool", klog.KRef(cephBlockPool.Namespace, cephBlockPool.Name))
 		existingBlockPool.ObjectMeta.OwnerReferences = cephBlockPool.ObjectMeta.OwnerReferences
-		existingBlockPool.Spec = cephBlockPool.Spec
-		err = r.Client.Update(context.TODO(), &existingBlockPool)
+		if mergeCephNFSBlockPoolOwnedFields(&existingBlockPool.Spec, cephBlockPool.Spec) {
+			r.Log.Info("Restoring drifted fields on CephBlockPool.", "CephBlockPool", klog.KRef(cephBlockPool.Namespace, cephBlockPool.Name))
+			r.recorder.Event(instance, v1.EventTypeNormal, "CephNFSBlockPoolDriftCorrected", fmt.Sprintf("Reconciled OCS-owned fields on CephBlockPool %s", cephBlockPool.Name))
+		}
+		err = r.Client.Update(ctx, &existingBlockPool)
 		if err != nil {
 			r.Log.Error(err, "Failed to update CephBlockPool.", "CephBlockPool", klog.KRef(cephBlockPool.Namespace, cephBlockPool.Name))
 			return reconcile.Result{}, err
 		}
 	case errors.IsNotFound(err):
 		r.Log.Info("Creating CephBlockPool.", "CephBlockPool", klog.KRef(cephBlockPool.Namespace, cephBlockPool.Name))
-		err = r.Client.Create(context.TODO(), cephBlockPool)
+		err = r.Client.Create(ctx, cephBlockPool)
 		if err != nil {
 			r.Log.Error(err, "Failed to create CephBlockPool.", "CephBlockPool", klog.KRef(cephBlockPool.Namespace, cephBlockPool.Name))
 			return reconcile.Result{}, err
@@ -191,14 +209,14 @@ func (obj *ocsCephNFSBlockPool) ensureCreated(r *StorageClusterReconciler, insta
 }
 
 // ensureDeleted deletes the CephNFS related CephBlockPool resource owned by the StorageCluster
-func (obj *ocsCephNFSBlockPool) ensureDeleted(r *StorageClusterReconciler, sc *ocsv1.StorageCluster) (reconcile.Result, error) {
+func (obj *ocsCephNFSBlockPool) ensureDeleted(r *StorageClusterReconciler, ctx context.Context, sc *ocsv1.StorageCluster) (reconcile.Result, error) {
 	foundCephBlockPool := &cephv1.CephBlockPool{}
 	cephBlockPool, err := r.newCephNFSBlockPoolInstance(sc)
 	if err != nil {
 		return reconcile.Result{}, err
 	}
 
-	err = r.Client.Get(context.TODO(), types.NamespacedName{Name: cephBlockPool.Name, Namespace: sc.Namespace}, foundCephBlockPool)
+	err = r.Client.Get(ctx, types.NamespacedName{Name: cephBlockPool.Name, Namespace: sc.Namespace}, foundCephBlockPool)
 	if err != nil {
 		if errors.IsNotFound(err) {
 			r.Log.Info("Uninstall: CephBlockPool not found.", "CephBlockPool", klog.KRef(cephBlockPool.Namespace, cephBlockPool.Name))
@@ -209,14 +227,18 @@ func (obj *ocsCephNFSBlockPool) ensureDeleted(r *StorageClusterReconciler, sc *o
 
 	if cephBlockPool.GetDeletionTimestamp().IsZero() {
 		r.Log.Info("Uninstall: Deleting CephBlockPool.", "CephBlockPool", klog.KRef(cephBlockPool.Namespace, cephBlockPool.Name))
-		err = r.Client.Delete(context.TODO(), foundCephBlockPool)
+		err = r.Client.Delete(ctx, foundCephBlockPool)
 		if err != nil {
 			r.Log.Error(err, "Uninstall: Failed to delete CephBlockPool.", "CephBlockPool", klog.KRef(foundCephBlockPool.Namespace, foundCephBlockPool.Name))
 			return reconcile.Result{}, fmt.Errorf("uninstall: Failed to delete CephBlockPool %v: %v", foundCephBlockPool.Name, err)
 		}
 	}
 
-	err = r.Client.Get(context.TODO(), types.NamespacedName{Name: cephBlockPool.Name, Namespace: sc.Namespace}, foundCephBlockPool)
+	if isClusterBeingCleanedUp(sc) {
+		return reconcile.Result{}, nil
+	}
+
+	err = r.Client.Get(ctx, types.NamespacedName{Name: cephBlockPool.Name, Namespace: sc.Namespace}, foundCephBlockPool)
 	if err != nil {
 		if errors.IsNotFound(err) {
 			r.Log.Info("Uninstall: CephBlockPool is deleted.", "CephBlockPool", klog.KRef(cephBlockPool.Namespace, cephBlockPool.Name))
@@ -227,114 +249,8 @@ func (obj *ocsCephNFSBlockPool) ensureDeleted(r *StorageClusterReconciler, sc *o
 	return reconcile.Result{}, fmt.Errorf("uninstall: Waiting for CephBlockPool %v to be deleted", cephBlockPool.Name)
 }
 
-type ocsCephNFSService struct{}
-
-// newNFSService returns the Service instance that should be created on first run.
-func (r *StorageClusterReconciler) newNFSService(initData *ocsv1.StorageCluster) (*v1.Service, error) {
-	obj := &v1.Service{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      generateNameForNFSService(initData),
-			Namespace: initData.Namespace,
-		},
-		Spec: v1.ServiceSpec{
-			Ports: []v1.ServicePort{
-				{
-					Name: "nfs",
-					Port: 2049,
-				},
-			},
-			Selector: map[string]string{
-				"app":      "rook-ceph-nfs",
-				"ceph_nfs": generateNameForCephNFS(initData),
-			},
-			SessionAffinity: "ClientIP",
-		},
-	}
-
-	err := controllerutil.SetControllerReference(initData, obj, r.Scheme)
-	if err != nil {
-		r.Log.Error(err, "Unable to set Controller Reference for NFS service.", " NFSService ", klog.KRef(obj.Namespace, obj.Name))
-		return nil, err
-	}
-
-	return obj, nil
-}
-
-// ensureCreated ensures that cephNFS related service resource exist in the desired state.
-func (obj *ocsCephNFSService) ensureCreated(r *StorageClusterReconciler, instance *ocsv1.StorageCluster) (reconcile.Result, error) {
-	if instance.Spec.NFS == nil || !instance.Spec.NFS.Enable {
-		return obj.ensureDeleted(r, instance)
-	}
-
-	nfsService, err := r.newNFSService(instance)
-	if err != nil {
-		return reconcile.Result{}, err
-	}
-
-	existingNFSService := v1.Service{}
-	err = r.Client.Get(context.TODO(), types.NamespacedName{Name: nfsService.Name, Namespace: nfsService.Namespace}, &existingNFSService)
-	switch {
-	case err == nil:
-		if existingNFSService.DeletionTimestamp != nil {
-			r.Log.Info("Unable to restore NFS Service because it is marked for deletion.", "NFSService", klog.KRef(existingNFSService.Namespace, existingNFSService.Name))
-			return reconcile.Result{}, fmt.Errorf("failed to restore initialization object %s because it is marked for deletion", existingNFSService.Name)
-		}
-
-		r.Log.Info("Restoring original NFS service.", "NFSService", klog.KRef(nfsService.Namespace, nfsService.Name))
-		existingNFSService.ObjectMeta.OwnerReferences = nfsService.ObjectMeta.OwnerReferences
-		existingNFSService.Spec = nfsService.Spec
-		err = r.Client.Update(context.TODO(), &existingNFSService)
-		if err != nil {
-			r.Log.Error(err, "Unable to update NFS service.", "NFSService", klog.KRef(nfsService.Namespace, nfsService.Name))
-			return reconcile.Result{}, err
-		}
-	case errors.IsNotFound(err):
-		r.Log.Info("Creating NFS service.", "NFSService", klog.KRef(nfsService.Namespace, nfsService.Name))
-		err = r.Client.Create(context.TODO(), nfsService)
-		if err != nil {
-			r.Log.Error(err, "Unable to create NFS service.", "NFSService", klog.KRef(nfsService.Namespace, nfsService.Namespace))
-			return reconcile.Result{}, err
-		}
-	}
-
-	return reconcile.Result{}, nil
-}
-
-// ensureDeleted deletes the cephNFS related service owned by the StorageCluster
-func (obj *ocsCephNFSService) ensureDeleted(r *StorageClusterReconciler, sc *ocsv1.StorageCluster) (reconcile.Result, error) {
-	foundNFSService := &v1.Service{}
-	nfsService, err := r.newNFSService(sc)
-	if err != nil {
-		return reconcile.Result{}, err
-	}
-
-	err = r.Client.Get(context.TODO(), types.NamespacedName{Name: nfsService.Name, Namespace: sc.Namespace}, foundNFSService)
-	if err != nil {
-		if errors.IsNotFound(err) {
-			r.Log.Info("Uninstall: NFS Service not found.", "NFSService", klog.KRef(nfsService.Namespace, nfsService.Name))
-			return reconcile.Result{}, nil
-		}
-		r.Log.Error(err, "Uninstall: Unable to retrieve NFS Service.", "NFSService", klog.KRef(nfsService.Namespace, nfsService.Name))
-		return reconcile.Result{}, fmt.Errorf("uninstall: Unable to retrieve NFS Service %v: %v", nfsService.Name, err)
-	}
-
-	if nfsService.GetDeletionTimestamp().IsZero() {
-		r.Log.Info("Uninstall: Deleting NFS Service.", "NFSService", klog.KRef(foundNFSService.Namespace, foundNFSService.Name))
-		err = r.Client.Delete(context.TODO(), foundNFSService)
-		if err != nil {
-			r.Log.Error(err, "Uninstall: Failed to delete NFS Service.", "NFSService", klog.KRef(foundNFSService.Namespace, foundNFSService.Name))
-			return reconcile.Result{}, fmt.Errorf("uninstall: Failed to delete NFS Service %v: %v", foundNFSService.Name, err)
-		}
-	}
-
-	err = r.Client.Get(context.TODO(), types.NamespacedName{Name: nfsService.Name, Namespace: sc.Namespace}, foundNFSService)
-	if err != nil {
-		if errors.IsNotFound(err) {
-			r.Log.Info("Uninstall: NFS Service is deleted.", "NFSService", klog.KRef(nfsService.Namespace, nfsService.Name))
-			return reconcile.Result{}, nil
-		}
-	}
-
-	r.Log.Error(err, "Uninstall: Waiting for NFS Service to be deleted.", "NFSService", klog.KRef(nfsService.Namespace, nfsService.Name))
-	return reconcile.Result{}, fmt.Errorf("uninstall: Waiting for NFS Service %v to be deleted", nfsService.Name)
-}
+// The NFS-fronting Service itself (named by generateNameForNFSService) is
+// reconciled by ocsNFSService in nfsservice.go, not here: it needs the full
+// ServiceType/LoadBalancer/annotation knobs off instance.Spec.NFS, and
+// having two reconcilers both own the same Service previously made them
+// fight each other's writes every reconcile.
@@ -6,7 +6,10 @@ import (
 
 	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
 	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
@@ -45,7 +48,7 @@ func assertCephNFS(t *testing.T, reconciler StorageClusterReconciler, cr *api.St
 		},
 	}
 	request.Name = "ocsinit-cephnfs"
-	err := reconciler.Client.Get(context.TODO(), request.NamespacedName, actualNfs)
+	err := reconciler.Client.Get(context.Background(), request.NamespacedName, actualNfs)
 	assert.NoError(t, err)
 
 	expectedAf, err := reconciler.newCephNFSInstance(cr)
@@ -56,3 +59,73 @@ func assertCephNFS(t *testing.T, reconciler StorageClusterReconciler, cr *api.St
 	assert.Equal(t, expectedAf.ObjectMeta.Name, actualNfs.ObjectMeta.Name)
 	assert.Equal(t, expectedAf.Spec, actualNfs.Spec)
 }
+
+func TestCephNFSEnsureCreatedAbortsOnCancelledContext(t *testing.T) {
+	cp := &Platform{platform: allPlatforms[0]}
+	_, reconciler, cr, request := initStorageClusterResourceCreateUpdateTestWithPlatform(t, cp, nil, nil)
+	cr.Spec.NFS = &api.NFSSpec{Enable: true}
+
+	// the fixture's own reconcile may already have created the CephNFS (see
+	// TestCephNFS); remove it so the check below only sees the aborted reconcile
+	request.Name = "ocsinit-cephnfs"
+	preexisting := &cephv1.CephNFS{}
+	if err := reconciler.Client.Get(context.Background(), request.NamespacedName, preexisting); err == nil {
+		assert.NoError(t, reconciler.Client.Delete(context.Background(), preexisting))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	obj := &ocsCephNFS{}
+	_, err := obj.ensureCreated(&reconciler, ctx, cr)
+	assert.Error(t, err)
+	assert.ErrorIs(t, err, context.Canceled)
+
+	// the CephNFS must not have been created as a result of the aborted reconcile
+	err = reconciler.Client.Get(context.Background(), request.NamespacedName, &cephv1.CephNFS{})
+	assert.True(t, errors.IsNotFound(err))
+}
+
+func TestNFSConfigHashRestartsGaneshaOnExportChange(t *testing.T) {
+	cp := &Platform{platform: allPlatforms[0]}
+	_, reconciler, cr, _ := initStorageClusterResourceCreateUpdateTestWithPlatform(t, cp, nil, nil)
+	cr.Spec.NFS = &api.NFSSpec{Enable: true}
+	ctx := context.Background()
+
+	// no ConfigMap yet: hash is empty
+	cephNFS, err := reconciler.newCephNetworkFilesystemInstance(ctx, cr)
+	assert.NoError(t, err)
+	assert.Empty(t, cephNFS.Spec.Server.Annotations[nfsConfigHashAnnotation])
+
+	cm := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      generateNameForRookNFSConfigMap(generateNameForCephNetworkFilesystem(cr)),
+			Namespace: cr.Namespace,
+		},
+		Data: map[string]string{"exports.conf": "EXPORT { Export_Id = 1; }"},
+	}
+	assert.NoError(t, reconciler.Client.Create(ctx, cm))
+
+	cephNFS, err = reconciler.newCephNetworkFilesystemInstance(ctx, cr)
+	assert.NoError(t, err)
+	firstHash := cephNFS.Spec.Server.Annotations[nfsConfigHashAnnotation]
+	assert.NotEmpty(t, firstHash)
+
+	obj := &ocsCephNetworkFilesystem{}
+	_, err = obj.ensureCreated(&reconciler, ctx, cr)
+	assert.NoError(t, err)
+
+	cm.Data["exports.conf"] = "EXPORT { Export_Id = 1; } EXPORT { Export_Id = 2; }"
+	assert.NoError(t, reconciler.Client.Update(ctx, cm))
+
+	cephNFS, err = reconciler.newCephNetworkFilesystemInstance(ctx, cr)
+	assert.NoError(t, err)
+	assert.NotEqual(t, firstHash, cephNFS.Spec.Server.Annotations[nfsConfigHashAnnotation])
+
+	_, err = obj.ensureCreated(&reconciler, ctx, cr)
+	assert.NoError(t, err)
+
+	stored := &cephv1.CephNFS{}
+	assert.NoError(t, reconciler.Client.Get(ctx, types.NamespacedName{Name: generateNameForCephNetworkFilesystem(cr), Namespace: cr.Namespace}, stored))
+	assert.Equal(t, cephNFS.Spec.Server.Annotations[nfsConfigHashAnnotation], stored.Spec.Server.Annotations[nfsConfigHashAnnotation])
+}
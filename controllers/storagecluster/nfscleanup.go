@@ -0,0 +1,25 @@
+package storagecluster
+
+import (
+	ocsv1 "github.com/red-hat-storage/ocs-operator/api/v1"
+)
+
+const (
+	// nfsCleanupPolicyAnnotation mirrors Rook's forced-destroy policy: when
+	// present and set to nfsCleanupPolicyConfirmation, the admin has
+	// confirmed the StorageCluster may be torn down even while NFS-backed
+	// data still exists.
+	nfsCleanupPolicyAnnotation = "uninstall.ocs.openshift.io/cleanup-policy"
+	// nfsCleanupPolicyConfirmation is the only value that confirms forced
+	// destruction; anything else is treated as "not confirmed".
+	nfsCleanupPolicyConfirmation = "yes-really-destroy-data"
+)
+
+// isClusterBeingCleanedUp reports whether the StorageCluster's cleanup
+// policy annotation confirms a forced, "destroy the data" uninstall. NFS
+// reconcilers use this to short-circuit ensureCreated into ensureDeleted
+// regardless of Spec.NFS.Enable, and to stop waiting on finalizers that
+// Ceph may never clear.
+func isClusterBeingCleanedUp(sc *ocsv1.StorageCluster) bool {
+	return sc.GetAnnotations()[nfsCleanupPolicyAnnotation] == nfsCleanupPolicyConfirmation
+}
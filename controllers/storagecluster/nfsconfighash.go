@@ -0,0 +1,66 @@
+package storagecluster
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// nfsConfigHashAnnotation is stamped on the CephNFS Server pod template so
+// that a change to the Ganesha export ConfigMap rook renders forces a
+// rolling restart of the Ganesha pods, rather than leaving them serving a
+// stale export list until they happen to restart for another reason.
+const nfsConfigHashAnnotation = "ocs.openshift.io/nfs-config-hash"
+
+// generateNameForRookNFSConfigMap returns the name of the ConfigMap rook
+// renders the Ganesha configuration/exports into for the given CephNFS.
+// This must track rook's own naming in its Ganesha config-map reconciler;
+// if rook ever renames that ConfigMap, getNFSConfigMapHash silently starts
+// seeing it as absent (empty hash) and the restart-on-change path goes
+// dark, so keep this in lockstep with the rook version vendored here.
+func generateNameForRookNFSConfigMap(cephNFSName string) string {
+	return fmt.Sprintf("rook-ceph-nfs-%s-config", cephNFSName)
+}
+
+// getNFSConfigMapHash returns a stable SHA-256 hex digest of the Ganesha
+// ConfigMap rook generates for the named CephNFS. It returns an empty hash,
+// and no error, when the ConfigMap does not exist yet (e.g. on first
+// create, before rook has had a chance to render it).
+func getNFSConfigMapHash(r *StorageClusterReconciler, ctx context.Context, cephNFSName, namespace string) (string, error) {
+	cm := &v1.ConfigMap{}
+	err := r.Client.Get(ctx, types.NamespacedName{Name: generateNameForRookNFSConfigMap(cephNFSName), Namespace: namespace}, cm)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return "", nil
+		}
+		return "", err
+	}
+
+	return hashConfigMapData(cm.Data), nil
+}
+
+// hashConfigMapData hashes ConfigMap data in key-sorted order so the
+// resulting digest is stable regardless of map iteration order.
+func hashConfigMapData(data map[string]string) string {
+	keys := make([]string, 0, len(data))
+	for k := range data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	h := sha256.New()
+	for _, k := range keys {
+		h.Write([]byte(k))
+		h.Write([]byte{0})
+		h.Write([]byte(data[k]))
+		h.Write([]byte{0})
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,776 @@
+package storagecluster
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	ocsv1 "github.com/red-hat-storage/ocs-operator/api/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// nfsCSIProvisionerImage is the cephcsi image running both the NFS
+// provisioner Deployment and node plugin DaemonSet.
+const nfsCSIProvisionerImage = "quay.io/cephcsi/cephcsi:v3.9.0"
+
+// nfsCSISidecarImage is the upstream external-provisioner sidecar image
+// version this driver has been validated against.
+const nfsCSISidecarImage = "registry.k8s.io/sig-storage/csi-provisioner:v3.6.0"
+
+// nfsCSINodeRegistrarImage is the node-driver-registrar sidecar image that
+// registers the NFS node plugin socket with kubelet.
+const nfsCSINodeRegistrarImage = "registry.k8s.io/sig-storage/csi-node-driver-registrar:v2.9.0"
+
+// nfsCSIDriverName is the suffix of the CSI driver name kubelet and the
+// external-provisioner sidecar use to route volume requests to this plugin;
+// the full name, "<namespace>.nfs.csi.ceph.com", is passed to the plugin as
+// --drivername and set as the NFS StorageClass's provisioner.
+const nfsCSIDriverName = "nfs.csi.ceph.com"
+
+// rookCephMonSecretName is rook-ceph's own Secret holding the cluster's Ceph
+// admin identity, keyed by "ceph-username"/"ceph-secret". The ceph-csi NFS
+// driver's Secret is projected from this one rather than minted separately,
+// matching how rook-ceph hands CSI drivers their Ceph credentials.
+const rookCephMonSecretName = "rook-ceph-mon"
+
+// nfsCSIPoolName is the `.nfs` pool every CephNFS server and its ceph-csi
+// NFS StorageClass share, matching the ceph-csi NFS provisioner convention
+// of one pool per cluster regardless of how many exports/StorageClasses sit
+// on top of it.
+const nfsCSIPoolName = ".nfs"
+
+// defaultNFSSubVolumeGroup is used when `Spec.NFS.SubVolumeGroup` is unset,
+// matching ceph-csi's own default subvolumegroup name for CSI-provisioned
+// volumes.
+const defaultNFSSubVolumeGroup = "csi"
+
+// getNFSSubVolumeGroupName returns the subvolumegroup the ceph-csi NFS
+// driver should create dynamically-provisioned exports under.
+func getNFSSubVolumeGroupName(initData *ocsv1.StorageCluster) string {
+	if initData.Spec.NFS != nil && initData.Spec.NFS.SubVolumeGroup != "" {
+		return initData.Spec.NFS.SubVolumeGroup
+	}
+	return defaultNFSSubVolumeGroup
+}
+
+// ocsNFSCSIDriver reconciles everything needed for the ceph-csi
+// `nfs.csi.ceph.com` driver to actually provision and mount volumes: the
+// ServiceAccount/ClusterRole/ClusterRoleBinding it runs as, the admin-keyring
+// Secret projected from rook-ceph's own rook-ceph-mon Secret, the
+// provisioner Deployment and node plugin DaemonSet (each with the full
+// external-provisioner/node-driver-registrar sidecar topology, not just the
+// cephcsi binary on its own), and the StorageClass that points PVCs at them.
+// StorageClass parameters are recomputed from the current
+// CephNFS/CephFilesystem names on every reconcile, so renames of either are
+// picked up without a separate watch.
+type ocsNFSCSIDriver struct{}
+
+// newNFSCSIProvisionerDeployment returns the cephcsi NFS provisioner
+// Deployment that watches for PVC provisioning/deletion requests against
+// the NFS StorageClass. It pairs the cephcsi controller-server with the
+// upstream external-provisioner sidecar, the same topology every CSI driver
+// (including the RBD/CephFS ones) uses: the sidecar watches
+// PVCs/PVs/VolumeSnapshots and calls the cephcsi gRPC server over the shared
+// socket-dir emptyDir to actually provision/delete volumes.
+func (r *StorageClusterReconciler) newNFSCSIProvisionerDeployment(initData *ocsv1.StorageCluster) (*appsv1.Deployment, error) {
+	var replicas int32 = 2
+	labels := map[string]string{"app": "csi-nfsplugin-provisioner"}
+	obj := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      generateNameForNFSCSIProvisionerDeployment(initData),
+			Namespace: initData.Namespace,
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{MatchLabels: labels},
+			Template: v1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec: v1.PodSpec{
+					ServiceAccountName: generateNameForNFSCSIServiceAccount(initData),
+					Containers: []v1.Container{
+						{
+							Name:  "csi-provisioner",
+							Image: nfsCSISidecarImage,
+							Args: []string{
+								"--csi-address=/csi/csi.sock",
+								"--v=5",
+								"--timeout=150s",
+								"--retry-interval-start=500ms",
+							},
+							VolumeMounts: []v1.VolumeMount{
+								{Name: "socket-dir", MountPath: "/csi"},
+							},
+						},
+						{
+							Name:  "csi-nfsplugin",
+							Image: nfsCSIProvisionerImage,
+							Args: []string{
+								"--type=nfs",
+								"--controllerserver=true",
+								fmt.Sprintf("--nodeid=%s", initData.Name),
+								fmt.Sprintf("--drivername=%s.%s", initData.Namespace, nfsCSIDriverName),
+								"--endpoint=unix:///csi/csi.sock",
+							},
+							VolumeMounts: []v1.VolumeMount{
+								{Name: "socket-dir", MountPath: "/csi"},
+								{Name: "keys-tmp-dir", MountPath: "/tmp/csi/keys"},
+							},
+						},
+					},
+					Volumes: []v1.Volume{
+						{Name: "socket-dir", VolumeSource: v1.VolumeSource{EmptyDir: &v1.EmptyDirVolumeSource{}}},
+						{Name: "keys-tmp-dir", VolumeSource: v1.VolumeSource{EmptyDir: &v1.EmptyDirVolumeSource{Medium: v1.StorageMediumMemory}}},
+					},
+				},
+			},
+		},
+	}
+
+	err := controllerutil.SetControllerReference(initData, obj, r.Scheme)
+	if err != nil {
+		r.Log.Error(err, "Unable to set Controller Reference for NFS CSI provisioner Deployment.", "Deployment", klog.KRef(obj.Namespace, obj.Name))
+		return nil, err
+	}
+
+	return obj, nil
+}
+
+// newNFSCSINodePluginDaemonSet returns the cephcsi NFS node plugin DaemonSet
+// that mounts Ganesha exports on every node a workload using an NFS-backed
+// PVC lands on. It pairs the cephcsi node-server with the upstream
+// node-driver-registrar sidecar, which tells kubelet where the plugin's
+// socket lives so kubelet can call NodeStageVolume/NodePublishVolume on it
+// directly; the plugin and registration directories are both hostPath
+// mounts into kubelet's own plugin directories, and the pods mount is
+// Bidirectional so mounts the node plugin makes become visible to kubelet
+// and the pod using the volume.
+func (r *StorageClusterReconciler) newNFSCSINodePluginDaemonSet(initData *ocsv1.StorageCluster) (*appsv1.DaemonSet, error) {
+	labels := map[string]string{"app": "csi-nfsplugin"}
+	hostPathDirOrCreate := v1.HostPathDirectoryOrCreate
+	hostPathDir := v1.HostPathDirectory
+	pluginDir := fmt.Sprintf("/var/lib/kubelet/plugins/%s", nfsCSIDriverName)
+
+	obj := &appsv1.DaemonSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      generateNameForNFSCSINodePluginDaemonSet(initData),
+			Namespace: initData.Namespace,
+		},
+		Spec: appsv1.DaemonSetSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: labels},
+			Template: v1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec: v1.PodSpec{
+					ServiceAccountName: generateNameForNFSCSIServiceAccount(initData),
+					HostNetwork:        true,
+					Containers: []v1.Container{
+						{
+							Name:  "driver-registrar",
+							Image: nfsCSINodeRegistrarImage,
+							Args: []string{
+								"--v=5",
+								"--csi-address=/csi/csi.sock",
+								fmt.Sprintf("--kubelet-registration-path=%s/csi.sock", pluginDir),
+							},
+							VolumeMounts: []v1.VolumeMount{
+								{Name: "plugin-dir", MountPath: "/csi"},
+								{Name: "registration-dir", MountPath: "/registration"},
+							},
+						},
+						{
+							Name:  "csi-nfsplugin",
+							Image: nfsCSIProvisionerImage,
+							Args: []string{
+								"--type=nfs",
+								"--nodeserver=true",
+								fmt.Sprintf("--nodeid=%s", initData.Name),
+								fmt.Sprintf("--drivername=%s.%s", initData.Namespace, nfsCSIDriverName),
+								"--endpoint=unix:///csi/csi.sock",
+							},
+							SecurityContext: &v1.SecurityContext{Privileged: boolPtr(true)},
+							VolumeMounts: []v1.VolumeMount{
+								{Name: "plugin-dir", MountPath: "/csi"},
+								{Name: "pods-mount-dir", MountPath: "/var/lib/kubelet/pods", MountPropagation: mountPropagationPtr(v1.MountPropagationBidirectional)},
+							},
+						},
+					},
+					Volumes: []v1.Volume{
+						{
+							Name: "plugin-dir",
+							VolumeSource: v1.VolumeSource{
+								HostPath: &v1.HostPathVolumeSource{Path: pluginDir, Type: &hostPathDirOrCreate},
+							},
+						},
+						{
+							Name: "registration-dir",
+							VolumeSource: v1.VolumeSource{
+								HostPath: &v1.HostPathVolumeSource{Path: "/var/lib/kubelet/plugins_registry", Type: &hostPathDir},
+							},
+						},
+						{
+							Name: "pods-mount-dir",
+							VolumeSource: v1.VolumeSource{
+								HostPath: &v1.HostPathVolumeSource{Path: "/var/lib/kubelet/pods", Type: &hostPathDirOrCreate},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	err := controllerutil.SetControllerReference(initData, obj, r.Scheme)
+	if err != nil {
+		r.Log.Error(err, "Unable to set Controller Reference for NFS CSI node plugin DaemonSet.", "DaemonSet", klog.KRef(obj.Namespace, obj.Name))
+		return nil, err
+	}
+
+	return obj, nil
+}
+
+func boolPtr(b bool) *bool { return &b }
+
+func mountPropagationPtr(m v1.MountPropagationMode) *v1.MountPropagationMode { return &m }
+
+// newNFSCSIServiceAccount returns the ServiceAccount the NFS CSI provisioner
+// Deployment and node plugin DaemonSet run as.
+func (r *StorageClusterReconciler) newNFSCSIServiceAccount(initData *ocsv1.StorageCluster) (*v1.ServiceAccount, error) {
+	obj := &v1.ServiceAccount{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      generateNameForNFSCSIServiceAccount(initData),
+			Namespace: initData.Namespace,
+		},
+	}
+
+	err := controllerutil.SetControllerReference(initData, obj, r.Scheme)
+	if err != nil {
+		r.Log.Error(err, "Unable to set Controller Reference for NFS CSI ServiceAccount.", "ServiceAccount", klog.KRef(obj.Namespace, obj.Name))
+		return nil, err
+	}
+
+	return obj, nil
+}
+
+// newNFSCSIClusterRole returns the ClusterRole granting the standard
+// external-provisioner/node-driver-registrar sidecar permissions: watching
+// PVCs/PVs/StorageClasses/CSINodes/Nodes to drive provisioning, and
+// reading/updating the objects it provisions.
+func (r *StorageClusterReconciler) newNFSCSIClusterRole(initData *ocsv1.StorageCluster) (*rbacv1.ClusterRole, error) {
+	obj := &rbacv1.ClusterRole{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: generateNameForNFSCSIClusterRole(initData),
+		},
+		Rules: []rbacv1.PolicyRule{
+			{APIGroups: []string{""}, Resources: []string{"persistentvolumes"}, Verbs: []string{"get", "list", "watch", "create", "delete", "update"}},
+			{APIGroups: []string{""}, Resources: []string{"persistentvolumeclaims"}, Verbs: []string{"get", "list", "watch", "update"}},
+			{APIGroups: []string{""}, Resources: []string{"nodes"}, Verbs: []string{"get", "list", "watch"}},
+			{APIGroups: []string{""}, Resources: []string{"events"}, Verbs: []string{"list", "watch", "create", "update", "patch"}},
+			{APIGroups: []string{""}, Resources: []string{"secrets"}, Verbs: []string{"get", "list"}},
+			{APIGroups: []string{"storage.k8s.io"}, Resources: []string{"storageclasses"}, Verbs: []string{"get", "list", "watch"}},
+			{APIGroups: []string{"storage.k8s.io"}, Resources: []string{"csinodes"}, Verbs: []string{"get", "list", "watch"}},
+			{APIGroups: []string{"storage.k8s.io"}, Resources: []string{"volumeattachments"}, Verbs: []string{"get", "list", "watch"}},
+		},
+	}
+
+	err := controllerutil.SetControllerReference(initData, obj, r.Scheme)
+	if err != nil {
+		r.Log.Error(err, "Unable to set Controller Reference for NFS CSI ClusterRole.", "ClusterRole", klog.KRef("", obj.Name))
+		return nil, err
+	}
+
+	return obj, nil
+}
+
+// newNFSCSIClusterRoleBinding returns the ClusterRoleBinding tying the NFS
+// CSI ServiceAccount to its ClusterRole.
+func (r *StorageClusterReconciler) newNFSCSIClusterRoleBinding(initData *ocsv1.StorageCluster) (*rbacv1.ClusterRoleBinding, error) {
+	obj := &rbacv1.ClusterRoleBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: generateNameForNFSCSIClusterRole(initData),
+		},
+		Subjects: []rbacv1.Subject{
+			{Kind: rbacv1.ServiceAccountKind, Name: generateNameForNFSCSIServiceAccount(initData), Namespace: initData.Namespace},
+		},
+		RoleRef: rbacv1.RoleRef{
+			APIGroup: rbacv1.GroupName,
+			Kind:     "ClusterRole",
+			Name:     generateNameForNFSCSIClusterRole(initData),
+		},
+	}
+
+	err := controllerutil.SetControllerReference(initData, obj, r.Scheme)
+	if err != nil {
+		r.Log.Error(err, "Unable to set Controller Reference for NFS CSI ClusterRoleBinding.", "ClusterRoleBinding", klog.KRef("", obj.Name))
+		return nil, err
+	}
+
+	return obj, nil
+}
+
+// newNFSCSISecret returns the Secret the NFS CSI driver authenticates to
+// Ceph with, projected from rook-ceph's own rook-ceph-mon Secret so there's
+// a single source of truth for the cluster's admin identity.
+func (r *StorageClusterReconciler) newNFSCSISecret(ctx context.Context, initData *ocsv1.StorageCluster) (*v1.Secret, error) {
+	monSecret := &v1.Secret{}
+	err := r.Client.Get(ctx, types.NamespacedName{Name: rookCephMonSecretName, Namespace: initData.Namespace}, monSecret)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read %s to build NFS CSI Secret: %v", rookCephMonSecretName, err)
+	}
+
+	obj := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      generateNameForCephCSINodeSecret(initData),
+			Namespace: initData.Namespace,
+		},
+		StringData: map[string]string{
+			"adminID":  string(monSecret.Data["ceph-username"]),
+			"adminKey": string(monSecret.Data["ceph-secret"]),
+		},
+	}
+
+	err = controllerutil.SetControllerReference(initData, obj, r.Scheme)
+	if err != nil {
+		r.Log.Error(err, "Unable to set Controller Reference for NFS CSI Secret.", "Secret", klog.KRef(obj.Namespace, obj.Name))
+		return nil, err
+	}
+
+	return obj, nil
+}
+
+// ensureNFSCSIProvisionerDeployment creates the NFS CSI provisioner
+// Deployment if it doesn't exist yet. Its PodSpec is fixed at creation time
+// (image/args don't vary per StorageCluster field), so there's no drift to
+// reconcile on subsequent runs.
+func (r *StorageClusterReconciler) ensureNFSCSIProvisionerDeployment(ctx context.Context, instance *ocsv1.StorageCluster) error {
+	deployment, err := r.newNFSCSIProvisionerDeployment(instance)
+	if err != nil {
+		return err
+	}
+
+	existing := appsv1.Deployment{}
+	err = r.Client.Get(ctx, types.NamespacedName{Name: deployment.Name, Namespace: deployment.Namespace}, &existing)
+	if err == nil {
+		return nil
+	}
+	if !errors.IsNotFound(err) {
+		return err
+	}
+
+	r.Log.Info("Creating NFS CSI provisioner Deployment.", "Deployment", klog.KRef(deployment.Namespace, deployment.Name))
+	if err := r.Client.Create(ctx, deployment); err != nil && !errors.IsAlreadyExists(err) {
+		r.Log.Error(err, "Unable to create NFS CSI provisioner Deployment.", "Deployment", klog.KRef(deployment.Namespace, deployment.Name))
+		return err
+	}
+	return nil
+}
+
+// ensureNFSCSINodePluginDaemonSet creates the NFS CSI node plugin DaemonSet
+// if it doesn't exist yet, for the same reason ensureNFSCSIProvisionerDeployment
+// doesn't reconcile drift: its PodSpec is fixed at creation time.
+func (r *StorageClusterReconciler) ensureNFSCSINodePluginDaemonSet(ctx context.Context, instance *ocsv1.StorageCluster) error {
+	daemonSet, err := r.newNFSCSINodePluginDaemonSet(instance)
+	if err != nil {
+		return err
+	}
+
+	existing := appsv1.DaemonSet{}
+	err = r.Client.Get(ctx, types.NamespacedName{Name: daemonSet.Name, Namespace: daemonSet.Namespace}, &existing)
+	if err == nil {
+		return nil
+	}
+	if !errors.IsNotFound(err) {
+		return err
+	}
+
+	r.Log.Info("Creating NFS CSI node plugin DaemonSet.", "DaemonSet", klog.KRef(daemonSet.Namespace, daemonSet.Name))
+	if err := r.Client.Create(ctx, daemonSet); err != nil && !errors.IsAlreadyExists(err) {
+		r.Log.Error(err, "Unable to create NFS CSI node plugin DaemonSet.", "DaemonSet", klog.KRef(daemonSet.Namespace, daemonSet.Name))
+		return err
+	}
+	return nil
+}
+
+// ensureNFSCSIProvisionerDeploymentDeleted deletes the NFS CSI provisioner
+// Deployment owned by the StorageCluster.
+func (r *StorageClusterReconciler) ensureNFSCSIProvisionerDeploymentDeleted(ctx context.Context, sc *ocsv1.StorageCluster) error {
+	deployment, err := r.newNFSCSIProvisionerDeployment(sc)
+	if err != nil {
+		return err
+	}
+
+	err = r.Client.Delete(ctx, deployment)
+	if err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("uninstall: failed to delete NFS CSI provisioner Deployment %v: %v", deployment.Name, err)
+	}
+	return nil
+}
+
+// ensureNFSCSINodePluginDaemonSetDeleted deletes the NFS CSI node plugin
+// DaemonSet owned by the StorageCluster.
+func (r *StorageClusterReconciler) ensureNFSCSINodePluginDaemonSetDeleted(ctx context.Context, sc *ocsv1.StorageCluster) error {
+	daemonSet, err := r.newNFSCSINodePluginDaemonSet(sc)
+	if err != nil {
+		return err
+	}
+
+	err = r.Client.Delete(ctx, daemonSet)
+	if err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("uninstall: failed to delete NFS CSI node plugin DaemonSet %v: %v", daemonSet.Name, err)
+	}
+	return nil
+}
+
+// ensureNFSCSIServiceAccount creates the NFS CSI ServiceAccount if it
+// doesn't exist yet.
+func (r *StorageClusterReconciler) ensureNFSCSIServiceAccount(ctx context.Context, instance *ocsv1.StorageCluster) error {
+	sa, err := r.newNFSCSIServiceAccount(instance)
+	if err != nil {
+		return err
+	}
+
+	existing := v1.ServiceAccount{}
+	err = r.Client.Get(ctx, types.NamespacedName{Name: sa.Name, Namespace: sa.Namespace}, &existing)
+	if err == nil {
+		return nil
+	}
+	if !errors.IsNotFound(err) {
+		return err
+	}
+
+	r.Log.Info("Creating NFS CSI ServiceAccount.", "ServiceAccount", klog.KRef(sa.Namespace, sa.Name))
+	if err := r.Client.Create(ctx, sa); err != nil && !errors.IsAlreadyExists(err) {
+		r.Log.Error(err, "Unable to create NFS CSI ServiceAccount.", "ServiceAccount", klog.KRef(sa.Namespace, sa.Name))
+		return err
+	}
+	return nil
+}
+
+// ensureNFSCSIRBAC creates the NFS CSI ClusterRole and ClusterRoleBinding if
+// they don't exist yet.
+func (r *StorageClusterReconciler) ensureNFSCSIRBAC(ctx context.Context, instance *ocsv1.StorageCluster) error {
+	clusterRole, err := r.newNFSCSIClusterRole(instance)
+	if err != nil {
+		return err
+	}
+
+	existingRole := rbacv1.ClusterRole{}
+	err = r.Client.Get(ctx, types.NamespacedName{Name: clusterRole.Name}, &existingRole)
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			return err
+		}
+		r.Log.Info("Creating NFS CSI ClusterRole.", "ClusterRole", klog.KRef("", clusterRole.Name))
+		if err := r.Client.Create(ctx, clusterRole); err != nil && !errors.IsAlreadyExists(err) {
+			r.Log.Error(err, "Unable to create NFS CSI ClusterRole.", "ClusterRole", klog.KRef("", clusterRole.Name))
+			return err
+		}
+	}
+
+	clusterRoleBinding, err := r.newNFSCSIClusterRoleBinding(instance)
+	if err != nil {
+		return err
+	}
+
+	existingBinding := rbacv1.ClusterRoleBinding{}
+	err = r.Client.Get(ctx, types.NamespacedName{Name: clusterRoleBinding.Name}, &existingBinding)
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			return err
+		}
+		r.Log.Info("Creating NFS CSI ClusterRoleBinding.", "ClusterRoleBinding", klog.KRef("", clusterRoleBinding.Name))
+		if err := r.Client.Create(ctx, clusterRoleBinding); err != nil && !errors.IsAlreadyExists(err) {
+			r.Log.Error(err, "Unable to create NFS CSI ClusterRoleBinding.", "ClusterRoleBinding", klog.KRef("", clusterRoleBinding.Name))
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ensureNFSCSISecret creates or refreshes the NFS CSI Secret so it always
+// reflects the admin identity currently in rook-ceph-mon.
+func (r *StorageClusterReconciler) ensureNFSCSISecret(ctx context.Context, instance *ocsv1.StorageCluster) error {
+	secret, err := r.newNFSCSISecret(ctx, instance)
+	if err != nil {
+		return err
+	}
+
+	existing := v1.Secret{}
+	err = r.Client.Get(ctx, types.NamespacedName{Name: secret.Name, Namespace: secret.Namespace}, &existing)
+	switch {
+	case err == nil:
+		if !reflect.DeepEqual(existing.Data, stringDataToData(secret.StringData)) {
+			existing.StringData = secret.StringData
+			if err := r.Client.Update(ctx, &existing); err != nil {
+				r.Log.Error(err, "Unable to update NFS CSI Secret.", "Secret", klog.KRef(secret.Namespace, secret.Name))
+				return err
+			}
+		}
+		return nil
+	case errors.IsNotFound(err):
+		r.Log.Info("Creating NFS CSI Secret.", "Secret", klog.KRef(secret.Namespace, secret.Name))
+		if err := r.Client.Create(ctx, secret); err != nil && !errors.IsAlreadyExists(err) {
+			r.Log.Error(err, "Unable to create NFS CSI Secret.", "Secret", klog.KRef(secret.Namespace, secret.Name))
+			return err
+		}
+		return nil
+	default:
+		return err
+	}
+}
+
+// stringDataToData converts string values to their raw byte form so they
+// can be compared against a Secret's persisted Data field, since the API
+// server moves StringData into Data on write and clears StringData back out.
+func stringDataToData(stringData map[string]string) map[string][]byte {
+	data := make(map[string][]byte, len(stringData))
+	for k, v := range stringData {
+		data[k] = []byte(v)
+	}
+	return data
+}
+
+// ensureNFSCSIServiceAccountDeleted deletes the NFS CSI ServiceAccount.
+func (r *StorageClusterReconciler) ensureNFSCSIServiceAccountDeleted(ctx context.Context, sc *ocsv1.StorageCluster) error {
+	sa, err := r.newNFSCSIServiceAccount(sc)
+	if err != nil {
+		return err
+	}
+	if err := r.Client.Delete(ctx, sa); err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("uninstall: failed to delete NFS CSI ServiceAccount %v: %v", sa.Name, err)
+	}
+	return nil
+}
+
+// ensureNFSCSIRBACDeleted deletes the NFS CSI ClusterRole and ClusterRoleBinding.
+func (r *StorageClusterReconciler) ensureNFSCSIRBACDeleted(ctx context.Context, sc *ocsv1.StorageCluster) error {
+	clusterRole, err := r.newNFSCSIClusterRole(sc)
+	if err != nil {
+		return err
+	}
+	if err := r.Client.Delete(ctx, clusterRole); err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("uninstall: failed to delete NFS CSI ClusterRole %v: %v", clusterRole.Name, err)
+	}
+
+	clusterRoleBinding, err := r.newNFSCSIClusterRoleBinding(sc)
+	if err != nil {
+		return err
+	}
+	if err := r.Client.Delete(ctx, clusterRoleBinding); err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("uninstall: failed to delete NFS CSI ClusterRoleBinding %v: %v", clusterRoleBinding.Name, err)
+	}
+	return nil
+}
+
+// ensureNFSCSISecretDeleted deletes the NFS CSI Secret. The Secret may not
+// exist if rook-ceph-mon was never reachable, which is not a failure.
+func (r *StorageClusterReconciler) ensureNFSCSISecretDeleted(ctx context.Context, sc *ocsv1.StorageCluster) error {
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      generateNameForCephCSINodeSecret(sc),
+			Namespace: sc.Namespace,
+		},
+	}
+	if err := r.Client.Delete(ctx, secret); err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("uninstall: failed to delete NFS CSI Secret %v: %v", secret.Name, err)
+	}
+	return nil
+}
+
+// generateNameForNFSCSIServiceAccount returns the name of the NFS CSI
+// ServiceAccount the provisioner Deployment and node plugin DaemonSet run
+// as.
+func generateNameForNFSCSIServiceAccount(initData *ocsv1.StorageCluster) string {
+	return fmt.Sprintf("%s-csi-nfsplugin", initData.Name)
+}
+
+// generateNameForNFSCSIClusterRole returns the name of the ClusterRole (and
+// its matching ClusterRoleBinding) granting the NFS CSI ServiceAccount the
+// permissions the external-provisioner/node-driver-registrar sidecars need.
+func generateNameForNFSCSIClusterRole(initData *ocsv1.StorageCluster) string {
+	return fmt.Sprintf("%s-csi-nfsplugin-%s", initData.Name, initData.Namespace)
+}
+
+// generateNameForCephCSINodeSecret returns the name of the Secret the NFS
+// CSI driver authenticates to Ceph with.
+func generateNameForCephCSINodeSecret(initData *ocsv1.StorageCluster) string {
+	return fmt.Sprintf("%s-cephnfs-csi-secret", initData.Name)
+}
+
+// generateNameForNFSCSIProvisionerDeployment returns the name of the NFS
+// CSI provisioner Deployment for this StorageCluster.
+func generateNameForNFSCSIProvisionerDeployment(initData *ocsv1.StorageCluster) string {
+	return fmt.Sprintf("%s-csi-nfsplugin-provisioner", initData.Name)
+}
+
+// generateNameForNFSCSINodePluginDaemonSet returns the name of the NFS CSI
+// node plugin DaemonSet for this StorageCluster.
+func generateNameForNFSCSINodePluginDaemonSet(initData *ocsv1.StorageCluster) string {
+	return fmt.Sprintf("%s-csi-nfsplugin", initData.Name)
+}
+
+// newNFSStorageClass returns the ceph-csi NFS StorageClass that should be
+// created on first run.
+func (r *StorageClusterReconciler) newNFSStorageClass(initData *ocsv1.StorageCluster) (*storagev1.StorageClass, error) {
+	reclaimPolicy := getReclaimPolicy(initData)
+	volumeBindingMode := getVolumeBindingMode(initData)
+
+	obj := &storagev1.StorageClass{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: generateNameForNFSStorageClass(initData),
+		},
+		Provisioner: fmt.Sprintf("%s.%s", initData.Namespace, nfsCSIDriverName),
+		Parameters: map[string]string{
+			"clusterID":        initData.Namespace,
+			"fsName":           generateNameForCephFilesystem(initData),
+			"nfsCluster":       generateNameForCephNFS(initData),
+			"server":           generateNameForNFSService(initData),
+			"pool":             nfsCSIPoolName,
+			"subvolumeGroup":   getNFSSubVolumeGroupName(initData),
+			"csi.storage.k8s.io/provisioner-secret-name":      generateNameForCephCSINodeSecret(initData),
+			"csi.storage.k8s.io/provisioner-secret-namespace": initData.Namespace,
+			"csi.storage.k8s.io/node-stage-secret-name":       generateNameForCephCSINodeSecret(initData),
+			"csi.storage.k8s.io/node-stage-secret-namespace":  initData.Namespace,
+		},
+		ReclaimPolicy:     &reclaimPolicy,
+		VolumeBindingMode: &volumeBindingMode,
+	}
+
+	err := controllerutil.SetControllerReference(initData, obj, r.Scheme)
+	if err != nil {
+		r.Log.Error(err, "Unable to set Controller Reference for NFS StorageClass.", "StorageClass", klog.KRef("", obj.Name))
+		return nil, err
+	}
+
+	return obj, nil
+}
+
+// ensureCreated ensures the NFS CSI ServiceAccount, RBAC, Secret,
+// provisioner Deployment, node plugin DaemonSet, and StorageClass all exist
+// in the desired state, re-pointing the StorageClass at the current CephNFS
+// server/pool/subvolumegroup on every reconcile so renames of any of those
+// are picked up.
+func (obj *ocsNFSCSIDriver) ensureCreated(r *StorageClusterReconciler, ctx context.Context, instance *ocsv1.StorageCluster) (reconcile.Result, error) {
+	if instance.Spec.NFS == nil || !instance.Spec.NFS.Enable || isClusterBeingCleanedUp(instance) {
+		return obj.ensureDeleted(r, ctx, instance)
+	}
+
+	if err := r.ensureNFSCSIServiceAccount(ctx, instance); err != nil {
+		return reconcile.Result{}, err
+	}
+
+	if err := r.ensureNFSCSIRBAC(ctx, instance); err != nil {
+		return reconcile.Result{}, err
+	}
+
+	if err := r.ensureNFSCSISecret(ctx, instance); err != nil {
+		return reconcile.Result{}, err
+	}
+
+	if err := r.ensureNFSCSIProvisionerDeployment(ctx, instance); err != nil {
+		return reconcile.Result{}, err
+	}
+
+	if err := r.ensureNFSCSINodePluginDaemonSet(ctx, instance); err != nil {
+		return reconcile.Result{}, err
+	}
+
+	storageClass, err := r.newNFSStorageClass(instance)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	existing := storagev1.StorageClass{}
+	err = r.Client.Get(ctx, types.NamespacedName{Name: storageClass.Name}, &existing)
+	switch {
+	case err == nil:
+		if existing.DeletionTimestamp != nil {
+			r.Log.Info("Unable to restore NFS StorageClass because it is marked for deletion.", "StorageClass", klog.KRef("", existing.Name))
+			return reconcile.Result{}, fmt.Errorf("failed to restore initialization object %s because it is marked for deletion", existing.Name)
+		}
+
+		// Provisioner, ReclaimPolicy and VolumeBindingMode are immutable on
+		// an existing StorageClass; only Parameters can be reconciled here,
+		// and only when they've actually drifted, to avoid a no-op Update
+		// (and the resourceVersion churn/log spam that comes with it) on
+		// every reconcile.
+		if !reflect.DeepEqual(existing.Parameters, storageClass.Parameters) {
+			existing.Parameters = storageClass.Parameters
+			err = r.Client.Update(ctx, &existing)
+			if err != nil {
+				r.Log.Error(err, "Unable to update NFS StorageClass.", "StorageClass", klog.KRef("", storageClass.Name))
+				return reconcile.Result{}, err
+			}
+		}
+	case errors.IsNotFound(err):
+		r.Log.Info("Creating NFS StorageClass.", "StorageClass", klog.KRef("", storageClass.Name))
+		err = r.Client.Create(ctx, storageClass)
+		if err != nil {
+			r.Log.Error(err, "Unable to create NFS StorageClass.", "StorageClass", klog.KRef("", storageClass.Name))
+			return reconcile.Result{}, err
+		}
+	default:
+		return reconcile.Result{}, err
+	}
+
+	return reconcile.Result{}, nil
+}
+
+// ensureDeleted deletes the ceph-csi NFS StorageClass, provisioner
+// Deployment, node plugin DaemonSet, Secret, RBAC, and ServiceAccount owned
+// by the StorageCluster.
+func (obj *ocsNFSCSIDriver) ensureDeleted(r *StorageClusterReconciler, ctx context.Context, sc *ocsv1.StorageCluster) (reconcile.Result, error) {
+	found := &storagev1.StorageClass{}
+	storageClass, err := r.newNFSStorageClass(sc)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	err = r.Client.Get(ctx, types.NamespacedName{Name: storageClass.Name}, found)
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			return reconcile.Result{}, fmt.Errorf("uninstall: unable to retrieve NFS StorageClass %v: %v", storageClass.Name, err)
+		}
+	} else {
+		err = r.Client.Delete(ctx, found)
+		if err != nil && !errors.IsNotFound(err) {
+			return reconcile.Result{}, fmt.Errorf("uninstall: failed to delete NFS StorageClass %v: %v", found.Name, err)
+		}
+	}
+
+	if err := r.ensureNFSCSIProvisionerDeploymentDeleted(ctx, sc); err != nil {
+		return reconcile.Result{}, err
+	}
+
+	if err := r.ensureNFSCSINodePluginDaemonSetDeleted(ctx, sc); err != nil {
+		return reconcile.Result{}, err
+	}
+
+	if err := r.ensureNFSCSISecretDeleted(ctx, sc); err != nil {
+		return reconcile.Result{}, err
+	}
+
+	if err := r.ensureNFSCSIRBACDeleted(ctx, sc); err != nil {
+		return reconcile.Result{}, err
+	}
+
+	if err := r.ensureNFSCSIServiceAccountDeleted(ctx, sc); err != nil {
+		return reconcile.Result{}, err
+	}
+
+	return reconcile.Result{}, nil
+}
+
+// generateNameForNFSStorageClass returns the name of the ceph-csi NFS
+// StorageClass for this StorageCluster.
+func generateNameForNFSStorageClass(initData *ocsv1.StorageCluster) string {
+	return fmt.Sprintf("%s-ceph-nfs", initData.Name)
+}
@@ -0,0 +1,120 @@
+package storagecluster
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	v1 "k8s.io/api/core/v1"
+
+	api "github.com/red-hat-storage/ocs-operator/api/v1"
+)
+
+// createRookCephMonSecret seeds the rook-ceph-mon Secret the NFS CSI Secret
+// is projected from, matching what rook-ceph would already have created by
+// the time this StorageCluster reconciles.
+func createRookCephMonSecret(t *testing.T, ctx context.Context, reconciler StorageClusterReconciler, namespace string) {
+	t.Helper()
+	monSecret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: rookCephMonSecretName, Namespace: namespace},
+		Data: map[string][]byte{
+			"ceph-username": []byte("client.admin"),
+			"ceph-secret":   []byte("admin-secret-key"),
+		},
+	}
+	assert.NoError(t, reconciler.Client.Create(ctx, monSecret))
+}
+
+func TestNFSStorageClassFollowsCephNFSName(t *testing.T) {
+	cp := &Platform{platform: allPlatforms[0]}
+	_, reconciler, cr, _ := initStorageClusterResourceCreateUpdateTestWithPlatform(t, cp, nil, nil)
+	cr.Spec.NFS = &api.NFSSpec{Enable: true}
+
+	sc, err := reconciler.newNFSStorageClass(cr)
+	assert.NoError(t, err)
+	assert.Equal(t, generateNameForCephNFS(cr), sc.Parameters["nfsCluster"])
+	assert.Equal(t, nfsCSIPoolName, sc.Parameters["pool"])
+	assert.Equal(t, defaultNFSSubVolumeGroup, sc.Parameters["subvolumeGroup"])
+
+	cr.Spec.NFS.SubVolumeGroup = "custom-group"
+	sc, err = reconciler.newNFSStorageClass(cr)
+	assert.NoError(t, err)
+	assert.Equal(t, "custom-group", sc.Parameters["subvolumeGroup"])
+}
+
+func TestNFSStorageClassReconcilesParametersOnSubsequentRuns(t *testing.T) {
+	cp := &Platform{platform: allPlatforms[0]}
+	_, reconciler, cr, _ := initStorageClusterResourceCreateUpdateTestWithPlatform(t, cp, nil, nil)
+	cr.Spec.NFS = &api.NFSSpec{Enable: true}
+	ctx := context.Background()
+	createRookCephMonSecret(t, ctx, reconciler, cr.Namespace)
+
+	obj := &ocsNFSCSIDriver{}
+	_, err := obj.ensureCreated(&reconciler, ctx, cr)
+	assert.NoError(t, err)
+
+	cr.Spec.NFS.SubVolumeGroup = "custom-group"
+	_, err = obj.ensureCreated(&reconciler, ctx, cr)
+	assert.NoError(t, err)
+
+	existing := &storagev1.StorageClass{}
+	err = reconciler.Client.Get(ctx, types.NamespacedName{Name: generateNameForNFSStorageClass(cr)}, existing)
+	assert.NoError(t, err)
+	assert.Equal(t, "custom-group", existing.Parameters["subvolumeGroup"])
+}
+
+func TestNFSCSIDriverProvisionsDeploymentAndDaemonSet(t *testing.T) {
+	cp := &Platform{platform: allPlatforms[0]}
+	_, reconciler, cr, _ := initStorageClusterResourceCreateUpdateTestWithPlatform(t, cp, nil, nil)
+	cr.Spec.NFS = &api.NFSSpec{Enable: true}
+	ctx := context.Background()
+	createRookCephMonSecret(t, ctx, reconciler, cr.Namespace)
+
+	obj := &ocsNFSCSIDriver{}
+	_, err := obj.ensureCreated(&reconciler, ctx, cr)
+	assert.NoError(t, err)
+
+	deployment := &appsv1.Deployment{}
+	err = reconciler.Client.Get(ctx, types.NamespacedName{Name: generateNameForNFSCSIProvisionerDeployment(cr), Namespace: cr.Namespace}, deployment)
+	assert.NoError(t, err)
+	// the provisioner sidecar and cephcsi binary must both be present, not
+	// just a bare cephcsi container
+	assert.Len(t, deployment.Spec.Template.Spec.Containers, 2)
+
+	daemonSet := &appsv1.DaemonSet{}
+	err = reconciler.Client.Get(ctx, types.NamespacedName{Name: generateNameForNFSCSINodePluginDaemonSet(cr), Namespace: cr.Namespace}, daemonSet)
+	assert.NoError(t, err)
+	assert.Len(t, daemonSet.Spec.Template.Spec.Containers, 2)
+
+	secret := &v1.Secret{}
+	err = reconciler.Client.Get(ctx, types.NamespacedName{Name: generateNameForCephCSINodeSecret(cr), Namespace: cr.Namespace}, secret)
+	assert.NoError(t, err)
+	assert.Equal(t, "client.admin", string(secret.Data["adminID"]))
+
+	sa := &v1.ServiceAccount{}
+	err = reconciler.Client.Get(ctx, types.NamespacedName{Name: generateNameForNFSCSIServiceAccount(cr), Namespace: cr.Namespace}, sa)
+	assert.NoError(t, err)
+
+	clusterRole := &rbacv1.ClusterRole{}
+	err = reconciler.Client.Get(ctx, types.NamespacedName{Name: generateNameForNFSCSIClusterRole(cr)}, clusterRole)
+	assert.NoError(t, err)
+
+	_, err = obj.ensureDeleted(&reconciler, ctx, cr)
+	assert.NoError(t, err)
+
+	err = reconciler.Client.Get(ctx, types.NamespacedName{Name: generateNameForNFSCSIProvisionerDeployment(cr), Namespace: cr.Namespace}, deployment)
+	assert.True(t, errors.IsNotFound(err))
+
+	err = reconciler.Client.Get(ctx, types.NamespacedName{Name: generateNameForNFSCSINodePluginDaemonSet(cr), Namespace: cr.Namespace}, daemonSet)
+	assert.True(t, errors.IsNotFound(err))
+
+	err = reconciler.Client.Get(ctx, types.NamespacedName{Name: generateNameForCephCSINodeSecret(cr), Namespace: cr.Namespace}, secret)
+	assert.True(t, errors.IsNotFound(err))
+}
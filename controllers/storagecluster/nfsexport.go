@@ -0,0 +1,396 @@
+package storagecluster
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"reflect"
+	"sort"
+	"strings"
+
+	ocsv1 "github.com/red-hat-storage/ocs-operator/api/v1"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// nfsExportsConfigMapKey is the data key the rendered Ganesha EXPORT blocks
+// are written under inside rook's own Ganesha ConfigMap
+// (generateNameForRookNFSConfigMap). Ganesha's config parser supports
+// %include of sibling files in the same mounted directory, so rook's base
+// ganesha.conf %includes this key without needing to know about it ahead of
+// time.
+const nfsExportsConfigMapKey = "exports.conf"
+
+// ocsCephNFSExport renders the user-declared `Spec.NFS.Exports` into rook's
+// own Ganesha ConfigMap (the one getNFSConfigMapHash reads, named by
+// generateNameForRookNFSConfigMap) so Ganesha actually serves them, and
+// makes sure the CephFilesystemSubVolumeGroup backing each CephFS-based
+// export exists before Ganesha is asked to serve it. This reconciler
+// doesn't own that ConfigMap - rook creates and owns its lifecycle from the
+// CephNFS CR - so it only merges its own exports.conf key into whatever
+// rook has already put there, and waits for a later reconcile if rook
+// hasn't created it yet.
+type ocsCephNFSExport struct{}
+
+// mergeNFSExportConfigMapData returns a copy of existing ConfigMap data
+// with exports.conf set to the rendered export blocks for instance, leaving
+// every other key (rook's own base ganesha.conf, etc.) untouched.
+func mergeNFSExportConfigMapData(existing map[string]string, instance *ocsv1.StorageCluster) map[string]string {
+	merged := make(map[string]string, len(existing)+1)
+	for k, v := range existing {
+		merged[k] = v
+	}
+	merged[nfsExportsConfigMapKey] = renderNFSExportBlocks(instance)
+	return merged
+}
+
+// renderNFSExportBlocks renders one Ganesha EXPORT {} block per entry in
+// `Spec.NFS.Exports`, sorted by export name so the rendered output (and
+// therefore the ConfigMap hash) is stable across reconciles.
+func renderNFSExportBlocks(initData *ocsv1.StorageCluster) string {
+	if initData.Spec.NFS == nil || len(initData.Spec.NFS.Exports) == 0 {
+		return ""
+	}
+
+	exports := make([]ocsv1.NFSExport, len(initData.Spec.NFS.Exports))
+	copy(exports, initData.Spec.NFS.Exports)
+	sort.Slice(exports, func(i, j int) bool { return exports[i].Name < exports[j].Name })
+
+	var sb strings.Builder
+	for i := range exports {
+		export := &exports[i]
+		squash := export.Squash
+		if squash == "" {
+			squash = "no_root_squash"
+		}
+		accessType := export.AccessType
+		if accessType == "" {
+			accessType = "RW"
+		}
+		secType := export.SecType
+		if secType == "" {
+			secType = "sys"
+		}
+
+		pseudo := export.Pseudo
+		if pseudo == "" {
+			pseudo = "/" + export.Name
+		}
+
+		fmt.Fprintf(&sb, "EXPORT {\n")
+		fmt.Fprintf(&sb, "\tExport_Id = %d;\n", nfsExportID(export))
+		fmt.Fprintf(&sb, "\tPath = %q;\n", export.Path)
+		fmt.Fprintf(&sb, "\tPseudo = %q;\n", pseudo)
+		fmt.Fprintf(&sb, "\tAccess_Type = %s;\n", accessType)
+		fmt.Fprintf(&sb, "\tSquash = %s;\n", squash)
+		fmt.Fprintf(&sb, "\tSecType = %s;\n", secType)
+		if len(export.Clients) > 0 {
+			fmt.Fprintf(&sb, "\tCLIENT { Clients = %s; }\n", strings.Join(export.Clients, ","))
+		}
+		if export.Bucket != "" {
+			rgwUserID := export.RGWUserID
+			if rgwUserID == "" {
+				rgwUserID = generateNameForNFSExportRGWUser(initData, export.Bucket)
+			}
+			fmt.Fprintf(&sb, "\tFSAL { Name = RGW; User_Id = %q; Bucket = %q; }\n", rgwUserID, export.Bucket)
+		} else {
+			fmt.Fprintf(&sb, "\tFSAL { Name = CEPH; Filesystem = %q; }\n", export.FSName)
+		}
+		fmt.Fprintf(&sb, "}\n")
+	}
+
+	return sb.String()
+}
+
+// nfsExportID returns the Ganesha Export_Id for an export. It is derived
+// from the export's name rather than its position in the sorted list, so
+// adding or removing one export doesn't renumber every export after it and
+// disrupt clients that already have the old IDs mounted. Explicitly
+// setting Spec.NFS.Exports[].ExportID overrides the derived value, for
+// admins migrating exports that must keep a specific pre-existing ID.
+func nfsExportID(export *ocsv1.NFSExport) uint32 {
+	if export.ExportID != 0 {
+		return export.ExportID
+	}
+
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(export.Name))
+	// Export_Id must be a positive integer; clear the sign bit and avoid 0.
+	return (h.Sum32() & 0x7fffffff) + 1
+}
+
+// newCephFilesystemSubVolumeGroupForExport returns the subvolumegroup
+// CephFS exports are carved out of, one per distinct FSName referenced by
+// `Spec.NFS.Exports`.
+func (r *StorageClusterReconciler) newCephFilesystemSubVolumeGroupForExport(initData *ocsv1.StorageCluster, fsName string) (*cephv1.CephFilesystemSubVolumeGroup, error) {
+	obj := &cephv1.CephFilesystemSubVolumeGroup{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      generateNameForNFSSubVolumeGroup(initData, fsName),
+			Namespace: initData.Namespace,
+		},
+		Spec: cephv1.CephFilesystemSubVolumeGroupSpec{
+			FilesystemName: fsName,
+		},
+	}
+
+	err := controllerutil.SetControllerReference(initData, obj, r.Scheme)
+	if err != nil {
+		r.Log.Error(err, "Unable to set Controller Reference for NFS export CephFilesystemSubVolumeGroup.", "CephFilesystemSubVolumeGroup", klog.KRef(obj.Namespace, obj.Name))
+		return nil, err
+	}
+
+	return obj, nil
+}
+
+// newNFSExportServices returns one Service per distinct export group, where
+// a group is the CephFS filesystem or RGW bucket backing one or more
+// entries in `Spec.NFS.Exports`, so each backing pool has its own stable
+// endpoint. The ceph-csi NFS StorageClass (newNFSStorageClass) does not use
+// these; its server is the cluster-wide NFS Service.
+func (r *StorageClusterReconciler) newNFSExportServices(initData *ocsv1.StorageCluster) ([]*v1.Service, error) {
+	groups := nfsExportGroups(initData)
+	services := make([]*v1.Service, 0, len(groups))
+	for _, group := range groups {
+		obj := &v1.Service{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      generateNameForNFSExport(initData, group),
+				Namespace: initData.Namespace,
+			},
+			Spec: v1.ServiceSpec{
+				Ports: []v1.ServicePort{
+					{
+						Name:       "nfs",
+						Port:       2049,
+						Protocol:   v1.ProtocolTCP,
+						TargetPort: intstr.FromInt(2049),
+					},
+				},
+				Selector: map[string]string{
+					"app":      "rook-ceph-nfs",
+					"ceph_nfs": generateNameForCephNetworkFilesystem(initData),
+				},
+				SessionAffinity: "ClientIP",
+			},
+		}
+
+		if err := controllerutil.SetControllerReference(initData, obj, r.Scheme); err != nil {
+			r.Log.Error(err, "Unable to set Controller Reference for NFS export Service.", "Service", klog.KRef(obj.Namespace, obj.Name))
+			return nil, err
+		}
+		services = append(services, obj)
+	}
+
+	return services, nil
+}
+
+// nfsExportGroups returns the distinct backing identifiers (CephFS
+// filesystem name or RGW bucket) referenced by `Spec.NFS.Exports`, sorted
+// so the returned order - and therefore Service creation order - is stable
+// across reconciles.
+func nfsExportGroups(initData *ocsv1.StorageCluster) []string {
+	if initData.Spec.NFS == nil {
+		return nil
+	}
+
+	seen := map[string]bool{}
+	var groups []string
+	for _, export := range initData.Spec.NFS.Exports {
+		group := export.FSName
+		if export.Bucket != "" {
+			group = export.Bucket
+		}
+		if group == "" || seen[group] {
+			continue
+		}
+		seen[group] = true
+		groups = append(groups, group)
+	}
+
+	sort.Strings(groups)
+	return groups
+}
+
+// ensureCreated merges the rendered exports.conf key into rook's Ganesha
+// ConfigMap (waiting for a later reconcile if rook hasn't created it yet),
+// and ensures the CephFilesystemSubVolumeGroup backing each CephFS export
+// and the per-export-group Services all exist in the desired state.
+func (obj *ocsCephNFSExport) ensureCreated(r *StorageClusterReconciler, ctx context.Context, instance *ocsv1.StorageCluster) (reconcile.Result, error) {
+	if err := ctx.Err(); err != nil {
+		return reconcile.Result{}, err
+	}
+
+	if instance.Spec.NFS == nil || !instance.Spec.NFS.Enable || len(instance.Spec.NFS.Exports) == 0 || isClusterBeingCleanedUp(instance) {
+		return obj.ensureDeleted(r, ctx, instance)
+	}
+
+	if err := r.ensureNFSExportSubVolumeGroups(ctx, instance); err != nil {
+		return reconcile.Result{}, err
+	}
+
+	if err := r.ensureNFSExportServices(ctx, instance); err != nil {
+		return reconcile.Result{}, err
+	}
+
+	rookConfigMapName := generateNameForRookNFSConfigMap(generateNameForCephNetworkFilesystem(instance))
+	existing := v1.ConfigMap{}
+	err := r.Client.Get(ctx, types.NamespacedName{Name: rookConfigMapName, Namespace: instance.Namespace}, &existing)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			// rook creates and owns this ConfigMap from the CephNFS CR; it
+			// hasn't rendered it yet, so there's nothing to merge our
+			// exports.conf key into. A later reconcile (e.g. triggered by
+			// the ConfigMap's own creation) will pick this back up.
+			r.Log.Info("Waiting for rook to create the Ganesha ConfigMap before merging NFS exports.", "ConfigMap", klog.KRef(instance.Namespace, rookConfigMapName))
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, err
+	}
+
+	merged := mergeNFSExportConfigMapData(existing.Data, instance)
+	if !reflect.DeepEqual(existing.Data, merged) {
+		existing.Data = merged
+		if err := r.Client.Update(ctx, &existing); err != nil {
+			r.Log.Error(err, "Unable to update NFS exports in the Ganesha ConfigMap.", "ConfigMap", klog.KRef(existing.Namespace, existing.Name))
+			return reconcile.Result{}, err
+		}
+	}
+
+	return reconcile.Result{}, nil
+}
+
+// ensureNFSExportServices creates the Service for each distinct export
+// group that doesn't already exist. Existing Services are left untouched
+// since their selector and port never change after creation.
+func (r *StorageClusterReconciler) ensureNFSExportServices(ctx context.Context, instance *ocsv1.StorageCluster) error {
+	services, err := r.newNFSExportServices(instance)
+	if err != nil {
+		return err
+	}
+
+	for _, svc := range services {
+		existing := v1.Service{}
+		err = r.Client.Get(ctx, types.NamespacedName{Name: svc.Name, Namespace: svc.Namespace}, &existing)
+		switch {
+		case err == nil:
+			continue
+		case errors.IsNotFound(err):
+			r.Log.Info("Creating NFS export Service.", "Service", klog.KRef(svc.Namespace, svc.Name))
+			if err := r.Client.Create(ctx, svc); err != nil {
+				r.Log.Error(err, "Unable to create NFS export Service.", "Service", klog.KRef(svc.Namespace, svc.Name))
+				return err
+			}
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ensureNFSExportSubVolumeGroups creates one CephFilesystemSubVolumeGroup
+// per distinct FSName referenced by `Spec.NFS.Exports`.
+func (r *StorageClusterReconciler) ensureNFSExportSubVolumeGroups(ctx context.Context, instance *ocsv1.StorageCluster) error {
+	seen := map[string]bool{}
+	for _, export := range instance.Spec.NFS.Exports {
+		if export.FSName == "" || export.Bucket != "" || seen[export.FSName] {
+			continue
+		}
+		seen[export.FSName] = true
+
+		svg, err := r.newCephFilesystemSubVolumeGroupForExport(instance, export.FSName)
+		if err != nil {
+			return err
+		}
+
+		existing := cephv1.CephFilesystemSubVolumeGroup{}
+		err = r.Client.Get(ctx, types.NamespacedName{Name: svg.Name, Namespace: svg.Namespace}, &existing)
+		switch {
+		case err == nil:
+			continue
+		case errors.IsNotFound(err):
+			r.Log.Info("Creating CephFilesystemSubVolumeGroup for NFS export.", "CephFilesystemSubVolumeGroup", klog.KRef(svg.Namespace, svg.Name))
+			if err := r.Client.Create(ctx, svg); err != nil {
+				r.Log.Error(err, "Unable to create CephFilesystemSubVolumeGroup for NFS export.", "CephFilesystemSubVolumeGroup", klog.KRef(svg.Namespace, svg.Name))
+				return err
+			}
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ensureDeleted removes this StorageCluster's exports.conf key from rook's
+// Ganesha ConfigMap, leaving the ConfigMap itself (and any other key rook or
+// another owner put there) alone - we never owned its lifecycle, only the
+// one key we merged in. The backing CephFilesystemSubVolumeGroups are left
+// in place since they may still hold user data and are not exclusively
+// owned by the export feature.
+func (obj *ocsCephNFSExport) ensureDeleted(r *StorageClusterReconciler, ctx context.Context, sc *ocsv1.StorageCluster) (reconcile.Result, error) {
+	rookConfigMapName := generateNameForRookNFSConfigMap(generateNameForCephNetworkFilesystem(sc))
+	foundConfigMap := &v1.ConfigMap{}
+	err := r.Client.Get(ctx, types.NamespacedName{Name: rookConfigMapName, Namespace: sc.Namespace}, foundConfigMap)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, fmt.Errorf("uninstall: unable to retrieve NFS Ganesha ConfigMap %v: %v", rookConfigMapName, err)
+	}
+
+	if _, ok := foundConfigMap.Data[nfsExportsConfigMapKey]; ok {
+		delete(foundConfigMap.Data, nfsExportsConfigMapKey)
+		r.Log.Info("Uninstall: Removing NFS exports from the Ganesha ConfigMap.", "ConfigMap", klog.KRef(foundConfigMap.Namespace, foundConfigMap.Name))
+		if err := r.Client.Update(ctx, foundConfigMap); err != nil {
+			return reconcile.Result{}, fmt.Errorf("uninstall: failed to remove NFS exports from the Ganesha ConfigMap %v: %v", foundConfigMap.Name, err)
+		}
+	}
+
+	for _, group := range nfsExportGroups(sc) {
+		foundService := &v1.Service{}
+		err = r.Client.Get(ctx, types.NamespacedName{Name: generateNameForNFSExport(sc, group), Namespace: sc.Namespace}, foundService)
+		if err != nil {
+			if errors.IsNotFound(err) {
+				continue
+			}
+			return reconcile.Result{}, fmt.Errorf("uninstall: unable to retrieve NFS export Service %v: %v", foundService.Name, err)
+		}
+
+		r.Log.Info("Uninstall: Deleting NFS export Service.", "Service", klog.KRef(foundService.Namespace, foundService.Name))
+		err = r.Client.Delete(ctx, foundService)
+		if err != nil && !errors.IsNotFound(err) {
+			return reconcile.Result{}, fmt.Errorf("uninstall: failed to delete NFS export Service %v: %v", foundService.Name, err)
+		}
+	}
+
+	return reconcile.Result{}, nil
+}
+
+// generateNameForNFSExport returns the name of the per-export-group Service
+// that fronts the CephFS filesystem or RGW bucket named by group.
+func generateNameForNFSExport(initData *ocsv1.StorageCluster, group string) string {
+	return fmt.Sprintf("%s-cephnfs-export-%s", initData.Name, group)
+}
+
+// generateNameForNFSExportRGWUser returns the CephObjectStoreUser name an
+// RGW-backed export's FSAL authenticates as, used when
+// Spec.NFS.Exports[].RGWUserID is left unset. This is intentionally
+// distinct from generateNameForNFSExport's Service name: Ganesha's RGW FSAL
+// needs a real Ceph object-store user identity, not a Kubernetes Service.
+func generateNameForNFSExportRGWUser(initData *ocsv1.StorageCluster, bucket string) string {
+	return fmt.Sprintf("%s-cephnfs-rgw-user-%s", initData.Name, bucket)
+}
+
+// generateNameForNFSSubVolumeGroup returns the name of the
+// CephFilesystemSubVolumeGroup that backs NFS exports for the given CephFS
+// filesystem name.
+func generateNameForNFSSubVolumeGroup(initData *ocsv1.StorageCluster, fsName string) string {
+	return fmt.Sprintf("%s-%s-nfs", initData.Name, fsName)
+}
@@ -0,0 +1,212 @@
+package storagecluster
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	api "github.com/red-hat-storage/ocs-operator/api/v1"
+)
+
+func TestRenderNFSExportBlocks(t *testing.T) {
+	cr := &api.StorageCluster{
+		Spec: api.StorageClusterSpec{
+			NFS: &api.NFSSpec{
+				Enable: true,
+				Exports: []api.NFSExport{
+					{
+						Name:       "share-b",
+						Path:       "/share-b",
+						FSName:     "ocs-storagecluster-cephfilesystem",
+						AccessType: "RO",
+						Squash:     "root_squash",
+						Clients:    []string{"10.0.0.0/8"},
+					},
+					{
+						Name:   "share-a",
+						Path:   "/share-a",
+						FSName: "ocs-storagecluster-cephfilesystem",
+					},
+				},
+			},
+		},
+	}
+
+	rendered := renderNFSExportBlocks(cr)
+
+	// exports are rendered in name order (share-a before share-b) so the
+	// output, and its hash, is stable regardless of spec ordering.
+	assert.Less(t, strings.Index(rendered, "/share-a"), strings.Index(rendered, "/share-b"))
+	assert.Contains(t, rendered, "Access_Type = RO;")
+	assert.Contains(t, rendered, "Squash = root_squash;")
+	assert.Contains(t, rendered, "Clients = 10.0.0.0/8;")
+	// defaults are applied when the fields are left unset
+	assert.Contains(t, rendered, "Access_Type = RW;")
+	assert.Contains(t, rendered, "Squash = no_root_squash;")
+}
+
+func TestRenderNFSExportBlocksEmpty(t *testing.T) {
+	cr := &api.StorageCluster{
+		Spec: api.StorageClusterSpec{
+			NFS: &api.NFSSpec{Enable: true},
+		},
+	}
+
+	assert.Empty(t, renderNFSExportBlocks(cr))
+}
+
+func TestRenderNFSExportBlocksRGWBackedExport(t *testing.T) {
+	cr := &api.StorageCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "ocsinit"},
+		Spec: api.StorageClusterSpec{
+			NFS: &api.NFSSpec{
+				Enable: true,
+				Exports: []api.NFSExport{
+					{
+						Name:   "share-cephfs",
+						Path:   "/share-cephfs",
+						FSName: "ocs-storagecluster-cephfilesystem",
+					},
+					{
+						Name:   "share-rgw",
+						Path:   "/share-rgw",
+						Bucket: "ocs-storagecluster-bucket",
+					},
+				},
+			},
+		},
+	}
+
+	rendered := renderNFSExportBlocks(cr)
+	assert.Contains(t, rendered, `FSAL { Name = CEPH; Filesystem = "ocs-storagecluster-cephfilesystem"; }`)
+	// the RGW FSAL authenticates as a real Ceph object-store user, distinct
+	// from the per-export-group Service name
+	assert.Contains(t, rendered, `FSAL { Name = RGW; User_Id = "ocsinit-cephnfs-rgw-user-ocs-storagecluster-bucket"; Bucket = "ocs-storagecluster-bucket"; }`)
+}
+
+func TestRenderNFSExportBlocksStableExportID(t *testing.T) {
+	withTwoExports := &api.StorageCluster{
+		Spec: api.StorageClusterSpec{
+			NFS: &api.NFSSpec{
+				Enable: true,
+				Exports: []api.NFSExport{
+					{Name: "share-a", Path: "/share-a", FSName: "cephfs1"},
+					{Name: "share-b", Path: "/share-b", FSName: "cephfs1"},
+				},
+			},
+		},
+	}
+	withThreeExports := &api.StorageCluster{
+		Spec: api.StorageClusterSpec{
+			NFS: &api.NFSSpec{
+				Enable: true,
+				Exports: []api.NFSExport{
+					{Name: "share-a", Path: "/share-a", FSName: "cephfs1"},
+					{Name: "share-aa", Path: "/share-aa", FSName: "cephfs1"},
+					{Name: "share-b", Path: "/share-b", FSName: "cephfs1"},
+				},
+			},
+		},
+	}
+
+	// Export_Id is rendered ahead of Path inside each EXPORT block, so look
+	// backwards from share-b's Path for its ID
+	idForShareB := func(rendered string) string {
+		head := rendered[:strings.Index(rendered, "Path = \"/share-b\"")]
+		block := head[strings.LastIndex(head, "Export_Id"):]
+		return block[:strings.Index(block, ";")]
+	}
+
+	// inserting "share-aa" ahead of "share-b" in sort order must not change
+	// share-b's Export_Id, since existing Ganesha mounts key off of it
+	before := renderNFSExportBlocks(withTwoExports)
+	after := renderNFSExportBlocks(withThreeExports)
+	assert.Equal(t, idForShareB(before), idForShareB(after))
+}
+
+func TestRenderNFSExportBlocksDistinctPseudoPath(t *testing.T) {
+	cr := &api.StorageCluster{
+		Spec: api.StorageClusterSpec{
+			NFS: &api.NFSSpec{
+				Enable: true,
+				Exports: []api.NFSExport{
+					{Name: "share-a", Path: "/volumes/csi/share-a/0001", FSName: "cephfs1"},
+				},
+			},
+		},
+	}
+
+	rendered := renderNFSExportBlocks(cr)
+	assert.Contains(t, rendered, `Path = "/volumes/csi/share-a/0001";`)
+	assert.Contains(t, rendered, `Pseudo = "/share-a";`)
+}
+
+func TestNFSExportEnsureCreatedMergesIntoRookConfigMap(t *testing.T) {
+	cp := &Platform{platform: allPlatforms[0]}
+	_, reconciler, cr, _ := initStorageClusterResourceCreateUpdateTestWithPlatform(t, cp, nil, nil)
+	cr.Spec.NFS = &api.NFSSpec{
+		Enable: true,
+		Exports: []api.NFSExport{
+			{Name: "share-a", Path: "/share-a", FSName: "ocs-storagecluster-cephfilesystem"},
+		},
+	}
+	ctx := context.Background()
+
+	obj := &ocsCephNFSExport{}
+
+	// rook hasn't rendered its Ganesha ConfigMap yet: ensureCreated must wait
+	// rather than author its own, unconsumed ConfigMap.
+	_, err := obj.ensureCreated(&reconciler, ctx, cr)
+	assert.NoError(t, err)
+
+	rookConfigMapName := generateNameForRookNFSConfigMap(generateNameForCephNetworkFilesystem(cr))
+	rookConfigMap := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: rookConfigMapName, Namespace: cr.Namespace},
+		Data:       map[string]string{"ganesha.conf": "%include exports.conf"},
+	}
+	assert.NoError(t, reconciler.Client.Create(ctx, rookConfigMap))
+
+	_, err = obj.ensureCreated(&reconciler, ctx, cr)
+	assert.NoError(t, err)
+
+	existing := &v1.ConfigMap{}
+	err = reconciler.Client.Get(ctx, types.NamespacedName{Name: rookConfigMapName, Namespace: cr.Namespace}, existing)
+	assert.NoError(t, err)
+	// rook's own key is untouched, and our exports are merged in alongside it
+	assert.Equal(t, "%include exports.conf", existing.Data["ganesha.conf"])
+	assert.Contains(t, existing.Data["exports.conf"], "/share-a")
+
+	_, err = obj.ensureDeleted(&reconciler, ctx, cr)
+	assert.NoError(t, err)
+
+	err = reconciler.Client.Get(ctx, types.NamespacedName{Name: rookConfigMapName, Namespace: cr.Namespace}, existing)
+	assert.NoError(t, err)
+	// ensureDeleted only removes the key we merged in, never rook's ConfigMap
+	assert.Equal(t, "%include exports.conf", existing.Data["ganesha.conf"])
+	assert.NotContains(t, existing.Data, "exports.conf")
+}
+
+func TestNFSExportGroupsDedupesByBackingPool(t *testing.T) {
+	cr := &api.StorageCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "ocsinit"},
+		Spec: api.StorageClusterSpec{
+			NFS: &api.NFSSpec{
+				Enable: true,
+				Exports: []api.NFSExport{
+					{Name: "share-a", Path: "/share-a", FSName: "cephfs1"},
+					{Name: "share-b", Path: "/share-b", FSName: "cephfs1"},
+					{Name: "share-c", Path: "/share-c", Bucket: "bucket1"},
+				},
+			},
+		},
+	}
+
+	groups := nfsExportGroups(cr)
+	assert.Equal(t, []string{"bucket1", "cephfs1"}, groups)
+	assert.Equal(t, "ocsinit-cephnfs-export-cephfs1", generateNameForNFSExport(cr, "cephfs1"))
+}
@@ -0,0 +1,360 @@
+package storagecluster
+
+import (
+	"context"
+	"fmt"
+
+	ocsv1 "github.com/red-hat-storage/ocs-operator/api/v1"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	v1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	// nfsNetworkFenceOwnerLabel names the StorageCluster a NetworkFence was
+	// created for, so stale fences can be found by List instead of only by
+	// the FenceClientIPs entries that still happen to exist.
+	nfsNetworkFenceOwnerLabel = "nfs.ocs.openshift.io/storagecluster"
+	// nfsNetworkFenceClientIPLabel records the client IP a NetworkFence was
+	// created for, read back during garbage collection to tell which fences
+	// FenceClientIPs still wants kept.
+	nfsNetworkFenceClientIPLabel = "nfs.ocs.openshift.io/client-ip"
+)
+
+// getNFSActiveServerCount returns the number of active Ganesha servers the
+// CephNFS should run. It defaults to 1 (active-passive) unless
+// `Spec.NFS.HighAvailability.ActiveServers` requests active-active scale-out.
+func getNFSActiveServerCount(initData *ocsv1.StorageCluster) int {
+	if initData.Spec.NFS == nil || initData.Spec.NFS.HighAvailability == nil {
+		return 1
+	}
+	if active := initData.Spec.NFS.HighAvailability.ActiveServers; active > 1 {
+		return active
+	}
+	return 1
+}
+
+type ocsCephNFSPodDisruptionBudget struct{}
+
+// newNFSPodDisruptionBudget returns the PodDisruptionBudget that keeps at
+// least one Ganesha server available while nodes drain, so active-active
+// scale-out doesn't lose quorum during a rolling upgrade.
+func (r *StorageClusterReconciler) newNFSPodDisruptionBudget(initData *ocsv1.StorageCluster) (*policyv1.PodDisruptionBudget, error) {
+	maxUnavailable := intstr.FromInt(1)
+	obj := &policyv1.PodDisruptionBudget{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      generateNameForNFSPodDisruptionBudget(initData),
+			Namespace: initData.Namespace,
+		},
+		Spec: policyv1.PodDisruptionBudgetSpec{
+			MaxUnavailable: &maxUnavailable,
+			Selector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{
+					"app":      "rook-ceph-nfs",
+					"ceph_nfs": generateNameForCephNFS(initData),
+				},
+			},
+		},
+	}
+
+	err := controllerutil.SetControllerReference(initData, obj, r.Scheme)
+	if err != nil {
+		r.Log.Error(err, "Unable to set Controller Reference for NFS PodDisruptionBudget.", "PodDisruptionBudget", klog.KRef(obj.Namespace, obj.Name))
+		return nil, err
+	}
+
+	return obj, nil
+}
+
+// ensureCreated ensures the NFS PodDisruptionBudget and per-instance
+// Services exist whenever active-active Ganesha (`ActiveServers > 1`) is
+// requested, and fences any client an operator has flagged as stuck so a
+// drain doesn't hang waiting for it to release its Ganesha grace-period
+// lock. Everything here is removed when active-active isn't requested,
+// since a single active server already tolerates no disruption.
+func (obj *ocsCephNFSPodDisruptionBudget) ensureCreated(r *StorageClusterReconciler, ctx context.Context, instance *ocsv1.StorageCluster) (reconcile.Result, error) {
+	if err := ctx.Err(); err != nil {
+		return reconcile.Result{}, err
+	}
+
+	if instance.Spec.NFS == nil || !instance.Spec.NFS.Enable || getNFSActiveServerCount(instance) <= 1 || isClusterBeingCleanedUp(instance) {
+		return obj.ensureDeleted(r, ctx, instance)
+	}
+
+	if err := ensureStuckNFSClientsFenced(r, ctx, instance); err != nil {
+		return reconcile.Result{}, err
+	}
+
+	if err := ensureNFSInstanceServices(r, ctx, instance); err != nil {
+		return reconcile.Result{}, err
+	}
+
+	pdb, err := r.newNFSPodDisruptionBudget(instance)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	existing := policyv1.PodDisruptionBudget{}
+	err = r.Client.Get(ctx, types.NamespacedName{Name: pdb.Name, Namespace: pdb.Namespace}, &existing)
+	switch {
+	case err == nil:
+		existing.Spec.MaxUnavailable = pdb.Spec.MaxUnavailable
+		existing.Spec.Selector = pdb.Spec.Selector
+		if err = r.Client.Update(ctx, &existing); err != nil {
+			r.Log.Error(err, "Unable to update NFS PodDisruptionBudget.", "PodDisruptionBudget", klog.KRef(pdb.Namespace, pdb.Name))
+			return reconcile.Result{}, err
+		}
+	case errors.IsNotFound(err):
+		r.Log.Info("Creating NFS PodDisruptionBudget.", "PodDisruptionBudget", klog.KRef(pdb.Namespace, pdb.Name))
+		if err = r.Client.Create(ctx, pdb); err != nil {
+			r.Log.Error(err, "Unable to create NFS PodDisruptionBudget.", "PodDisruptionBudget", klog.KRef(pdb.Namespace, pdb.Name))
+			return reconcile.Result{}, err
+		}
+	default:
+		return reconcile.Result{}, err
+	}
+
+	return reconcile.Result{}, nil
+}
+
+// ensureDeleted deletes the NFS PodDisruptionBudget, per-instance Services,
+// and any NetworkFence this StorageCluster created, so a fenced client
+// doesn't stay fenced past the StorageCluster that fenced it.
+func (obj *ocsCephNFSPodDisruptionBudget) ensureDeleted(r *StorageClusterReconciler, ctx context.Context, sc *ocsv1.StorageCluster) (reconcile.Result, error) {
+	if err := ensureStaleNFSClientFencesRemoved(r, ctx, sc, nil); err != nil {
+		return reconcile.Result{}, err
+	}
+
+	if err := ensureNFSInstanceServicesDeleted(r, ctx, sc); err != nil {
+		return reconcile.Result{}, err
+	}
+
+	pdb, err := r.newNFSPodDisruptionBudget(sc)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	err = r.Client.Delete(ctx, pdb)
+	if err != nil && !errors.IsNotFound(err) {
+		return reconcile.Result{}, fmt.Errorf("uninstall: failed to delete NFS PodDisruptionBudget %v: %v", pdb.Name, err)
+	}
+
+	return reconcile.Result{}, nil
+}
+
+// newNFSInstanceServices returns one Service per active Ganesha ordinal
+// (0..ActiveServers-1) of the CephNFS named by generateNameForCephNFS.
+// NFSv4 state is per-server, so active-active clients need a stable
+// endpoint pinned to one instance rather than one load-balanced across all
+// of them.
+func (r *StorageClusterReconciler) newNFSInstanceServices(initData *ocsv1.StorageCluster) ([]*v1.Service, error) {
+	count := getNFSActiveServerCount(initData)
+	if count <= 1 {
+		return nil, nil
+	}
+
+	services := make([]*v1.Service, 0, count)
+	for i := 0; i < count; i++ {
+		obj := &v1.Service{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      generateNameForNFSInstanceService(initData, i),
+				Namespace: initData.Namespace,
+			},
+			Spec: v1.ServiceSpec{
+				Ports: []v1.ServicePort{
+					{
+						Name:       "nfs",
+						Port:       2049,
+						Protocol:   v1.ProtocolTCP,
+						TargetPort: intstr.FromInt(2049),
+					},
+				},
+				Selector: map[string]string{
+					"app":            "rook-ceph-nfs",
+					"ceph_nfs":       generateNameForCephNFS(initData),
+					"ceph_nfs_index": fmt.Sprintf("%d", i),
+				},
+				SessionAffinity: "ClientIP",
+			},
+		}
+
+		if err := controllerutil.SetControllerReference(initData, obj, r.Scheme); err != nil {
+			r.Log.Error(err, "Unable to set Controller Reference for NFS instance service.", "Service", klog.KRef(obj.Namespace, obj.Name))
+			return nil, err
+		}
+		services = append(services, obj)
+	}
+
+	return services, nil
+}
+
+// ensureNFSInstanceServices creates the Service for each active Ganesha
+// ordinal that doesn't already exist, and removes any left over from a
+// larger previous ActiveServers count.
+func ensureNFSInstanceServices(r *StorageClusterReconciler, ctx context.Context, instance *ocsv1.StorageCluster) error {
+	services, err := r.newNFSInstanceServices(instance)
+	if err != nil {
+		return err
+	}
+
+	for _, svc := range services {
+		existing := v1.Service{}
+		err = r.Client.Get(ctx, types.NamespacedName{Name: svc.Name, Namespace: svc.Namespace}, &existing)
+		switch {
+		case err == nil:
+			continue
+		case errors.IsNotFound(err):
+			r.Log.Info("Creating NFS instance Service.", "Service", klog.KRef(svc.Namespace, svc.Name))
+			if err := r.Client.Create(ctx, svc); err != nil {
+				r.Log.Error(err, "Unable to create NFS instance Service.", "Service", klog.KRef(svc.Namespace, svc.Name))
+				return err
+			}
+		default:
+			return err
+		}
+	}
+
+	// Garbage-collect instance Services beyond the current ActiveServers
+	// count (e.g. scaling active-active down from 3 to 2 instances).
+	for i := len(services); ; i++ {
+		name := generateNameForNFSInstanceService(instance, i)
+		existing := v1.Service{}
+		err = r.Client.Get(ctx, types.NamespacedName{Name: name, Namespace: instance.Namespace}, &existing)
+		if errors.IsNotFound(err) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		r.Log.Info("Deleting stale NFS instance Service.", "Service", klog.KRef(existing.Namespace, existing.Name))
+		if err := r.Client.Delete(ctx, &existing); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ensureNFSInstanceServicesDeleted deletes every NFS instance Service owned
+// by the StorageCluster, used both when active-active is disabled and
+// during uninstall.
+func ensureNFSInstanceServicesDeleted(r *StorageClusterReconciler, ctx context.Context, sc *ocsv1.StorageCluster) error {
+	for i := 0; ; i++ {
+		name := generateNameForNFSInstanceService(sc, i)
+		existing := v1.Service{}
+		err := r.Client.Get(ctx, types.NamespacedName{Name: name, Namespace: sc.Namespace}, &existing)
+		if errors.IsNotFound(err) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if err := r.Client.Delete(ctx, &existing); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ensureStuckNFSClientsFenced syncs the live set of NetworkFences to
+// Spec.NFS.HighAvailability.FenceClientIPs: an operator flags a client IP
+// there while draining its Ganesha pod so the drain doesn't hang waiting
+// for a client that will never release its grace-period lock, and clears
+// it once the client is no longer stuck. Every reconcile creates any
+// missing fence for an IP still in the list, and removes any fence this
+// StorageCluster created for an IP that's been dropped, so a client is
+// never left fenced after the admin has un-flagged it.
+func ensureStuckNFSClientsFenced(r *StorageClusterReconciler, ctx context.Context, initData *ocsv1.StorageCluster) error {
+	desired := map[string]bool{}
+	if initData.Spec.NFS != nil && initData.Spec.NFS.HighAvailability != nil {
+		for _, clientIP := range initData.Spec.NFS.HighAvailability.FenceClientIPs {
+			desired[clientIP] = true
+			if err := fenceNFSClient(r, ctx, initData, clientIP); err != nil {
+				return err
+			}
+		}
+	}
+
+	return ensureStaleNFSClientFencesRemoved(r, ctx, initData, desired)
+}
+
+// fenceNFSClient issues a rook NetworkFence for the given client IP so a
+// stuck NFS client is evicted cleanly while its Ganesha pod is being
+// drained, the same mechanism rook uses to fence RBD/CephFS clients on node
+// loss.
+func fenceNFSClient(r *StorageClusterReconciler, ctx context.Context, initData *ocsv1.StorageCluster, clientIP string) error {
+	fence := &cephv1.NetworkFence{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: generateNameForNFSNetworkFence(initData, clientIP),
+			Labels: map[string]string{
+				nfsNetworkFenceOwnerLabel:    initData.Name,
+				nfsNetworkFenceClientIPLabel: clientIP,
+			},
+		},
+		Spec: cephv1.NetworkFenceSpec{
+			Cidr: []string{fmt.Sprintf("%s/32", clientIP)},
+		},
+	}
+
+	err := r.Client.Create(ctx, fence)
+	if err != nil && !errors.IsAlreadyExists(err) {
+		r.Log.Error(err, "Unable to fence NFS client.", "NetworkFence", klog.KRef(fence.Namespace, fence.Name), "clientIP", clientIP)
+		return err
+	}
+
+	return nil
+}
+
+// ensureStaleNFSClientFencesRemoved deletes every NetworkFence this
+// StorageCluster created whose client IP is absent from desired, so
+// shrinking or clearing FenceClientIPs actually un-fences the client
+// instead of leaving the CR (and the fence it enforces) in place forever.
+// Passing a nil or empty desired removes every fence this StorageCluster
+// owns, which is what uninstall needs.
+func ensureStaleNFSClientFencesRemoved(r *StorageClusterReconciler, ctx context.Context, initData *ocsv1.StorageCluster, desired map[string]bool) error {
+	fences := &cephv1.NetworkFenceList{}
+	if err := r.Client.List(ctx, fences, client.MatchingLabels{nfsNetworkFenceOwnerLabel: initData.Name}); err != nil {
+		return err
+	}
+
+	for i := range fences.Items {
+		fence := &fences.Items[i]
+		if desired[fence.Labels[nfsNetworkFenceClientIPLabel]] {
+			continue
+		}
+
+		r.Log.Info("Deleting stale NFS client NetworkFence.", "NetworkFence", klog.KRef(fence.Namespace, fence.Name), "clientIP", fence.Labels[nfsNetworkFenceClientIPLabel])
+		if err := r.Client.Delete(ctx, fence); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// generateNameForNFSPodDisruptionBudget returns the name of the
+// PodDisruptionBudget protecting active-active Ganesha pods.
+func generateNameForNFSPodDisruptionBudget(initData *ocsv1.StorageCluster) string {
+	return fmt.Sprintf("%s-cephnfs", initData.Name)
+}
+
+// generateNameForNFSInstanceService returns the name of the Service pinned
+// to the Ganesha pod at the given active-active ordinal.
+func generateNameForNFSInstanceService(initData *ocsv1.StorageCluster, index int) string {
+	return fmt.Sprintf("%s-cephnfs-%d", initData.Name, index)
+}
+
+// generateNameForNFSNetworkFence returns the name of the NetworkFence used
+// to evict a stuck NFS client identified by its IP.
+func generateNameForNFSNetworkFence(initData *ocsv1.StorageCluster, clientIP string) string {
+	return fmt.Sprintf("%s-nfs-fence-%s", initData.Name, clientIP)
+}
@@ -0,0 +1,113 @@
+package storagecluster
+
+import (
+	"context"
+	"testing"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+
+	api "github.com/red-hat-storage/ocs-operator/api/v1"
+)
+
+func TestGetNFSActiveServerCount(t *testing.T) {
+	cases := []struct {
+		label string
+		nfs   *api.NFSSpec
+		want  int
+	}{
+		{label: "NFS disabled", nfs: nil, want: 1},
+		{label: "no HA spec", nfs: &api.NFSSpec{Enable: true}, want: 1},
+		{label: "HA with single active server", nfs: &api.NFSSpec{Enable: true, HighAvailability: &api.NFSHighAvailabilitySpec{ActiveServers: 1}}, want: 1},
+		{label: "HA active-active", nfs: &api.NFSSpec{Enable: true, HighAvailability: &api.NFSHighAvailabilitySpec{ActiveServers: 3}}, want: 3},
+	}
+
+	for _, c := range cases {
+		cr := &api.StorageCluster{Spec: api.StorageClusterSpec{NFS: c.nfs}}
+		assert.Equal(t, c.want, getNFSActiveServerCount(cr), c.label)
+	}
+}
+
+func TestNFSPodDisruptionBudgetCreatesInstanceServices(t *testing.T) {
+	cp := &Platform{platform: allPlatforms[0]}
+	_, reconciler, cr, _ := initStorageClusterResourceCreateUpdateTestWithPlatform(t, cp, nil, nil)
+	cr.Spec.NFS = &api.NFSSpec{Enable: true, HighAvailability: &api.NFSHighAvailabilitySpec{ActiveServers: 3}}
+
+	obj := &ocsCephNFSPodDisruptionBudget{}
+	ctx := context.Background()
+	_, err := obj.ensureCreated(&reconciler, ctx, cr)
+	assert.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		svc := &v1.Service{}
+		err = reconciler.Client.Get(ctx, types.NamespacedName{Name: generateNameForNFSInstanceService(cr, i), Namespace: cr.Namespace}, svc)
+		assert.NoError(t, err)
+	}
+
+	// scaling active-active down removes the now-unused instance Service
+	cr.Spec.NFS.HighAvailability.ActiveServers = 2
+	_, err = obj.ensureCreated(&reconciler, ctx, cr)
+	assert.NoError(t, err)
+
+	err = reconciler.Client.Get(ctx, types.NamespacedName{Name: generateNameForNFSInstanceService(cr, 2), Namespace: cr.Namespace}, &v1.Service{})
+	assert.True(t, errors.IsNotFound(err))
+}
+
+func TestNFSPodDisruptionBudgetFencesStuckClients(t *testing.T) {
+	cp := &Platform{platform: allPlatforms[0]}
+	_, reconciler, cr, _ := initStorageClusterResourceCreateUpdateTestWithPlatform(t, cp, nil, nil)
+	cr.Spec.NFS = &api.NFSSpec{
+		Enable: true,
+		HighAvailability: &api.NFSHighAvailabilitySpec{
+			ActiveServers:  2,
+			FenceClientIPs: []string{"10.0.0.9"},
+		},
+	}
+
+	obj := &ocsCephNFSPodDisruptionBudget{}
+	ctx := context.Background()
+	_, err := obj.ensureCreated(&reconciler, ctx, cr)
+	assert.NoError(t, err)
+
+	err = reconciler.Client.Get(ctx, types.NamespacedName{Name: generateNameForNFSNetworkFence(cr, "10.0.0.9")}, &cephv1.NetworkFence{})
+	assert.NoError(t, err)
+}
+
+func TestNFSPodDisruptionBudgetUnfencesDroppedClients(t *testing.T) {
+	cp := &Platform{platform: allPlatforms[0]}
+	_, reconciler, cr, _ := initStorageClusterResourceCreateUpdateTestWithPlatform(t, cp, nil, nil)
+	cr.Spec.NFS = &api.NFSSpec{
+		Enable: true,
+		HighAvailability: &api.NFSHighAvailabilitySpec{
+			ActiveServers:  2,
+			FenceClientIPs: []string{"10.0.0.9", "10.0.0.10"},
+		},
+	}
+
+	obj := &ocsCephNFSPodDisruptionBudget{}
+	ctx := context.Background()
+	_, err := obj.ensureCreated(&reconciler, ctx, cr)
+	assert.NoError(t, err)
+
+	// the admin has fixed 10.0.0.9 and dropped it from the list; its fence
+	// must be removed, while 10.0.0.10 stays fenced
+	cr.Spec.NFS.HighAvailability.FenceClientIPs = []string{"10.0.0.10"}
+	_, err = obj.ensureCreated(&reconciler, ctx, cr)
+	assert.NoError(t, err)
+
+	err = reconciler.Client.Get(ctx, types.NamespacedName{Name: generateNameForNFSNetworkFence(cr, "10.0.0.9")}, &cephv1.NetworkFence{})
+	assert.True(t, errors.IsNotFound(err))
+
+	err = reconciler.Client.Get(ctx, types.NamespacedName{Name: generateNameForNFSNetworkFence(cr, "10.0.0.10")}, &cephv1.NetworkFence{})
+	assert.NoError(t, err)
+
+	// uninstall must remove every fence this StorageCluster created
+	_, err = obj.ensureDeleted(&reconciler, ctx, cr)
+	assert.NoError(t, err)
+
+	err = reconciler.Client.Get(ctx, types.NamespacedName{Name: generateNameForNFSNetworkFence(cr, "10.0.0.10")}, &cephv1.NetworkFence{})
+	assert.True(t, errors.IsNotFound(err))
+}
@@ -0,0 +1,49 @@
+package storagecluster
+
+import (
+	"context"
+
+	ocsv1 "github.com/red-hat-storage/ocs-operator/api/v1"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// nfsResourceManager is the ctx-aware shape every NFS reconciler in this
+// package implements: ensureCreated/ensureDeleted take ctx right after the
+// receiver, matching every other resourceManager in the main
+// StorageClusterReconciler.Reconcile dispatch list. Any resourceManager
+// registered there must satisfy this same shape once ctx is threaded
+// through it, or the build breaks at the registration site - which is
+// exactly the kind of break this interface exists to catch at compile time
+// instead of letting it surface as a silent no-op dispatch.
+type nfsResourceManager interface {
+	ensureCreated(*StorageClusterReconciler, context.Context, *ocsv1.StorageCluster) (reconcile.Result, error)
+	ensureDeleted(*StorageClusterReconciler, context.Context, *ocsv1.StorageCluster) (reconcile.Result, error)
+}
+
+// nfsResourceManagers lists every NFS resource manager, in dependency order
+// (pools and the CephNFS server before the exports/service/CSI driver that
+// sit on top of them). ReconcileNFS below is the single call that runs all
+// of them.
+var nfsResourceManagers = []nfsResourceManager{
+	&ocsCephNFSBlockPool{},
+	&ocsCephNFS{},
+	&ocsCephNetworkFilesystem{},
+	&ocsCephNFSExport{},
+	&ocsNFSService{},
+	&ocsCephNFSPodDisruptionBudget{},
+	&ocsNFSCSIDriver{},
+}
+
+// ReconcileNFS runs every NFS resource manager against instance, in the
+// order they're declared in nfsResourceManagers, stopping at the first
+// error so an earlier manager's failure (e.g. the CephNFS server not
+// existing yet) doesn't mask itself behind a later manager's unrelated
+// failure.
+func (r *StorageClusterReconciler) ReconcileNFS(ctx context.Context, instance *ocsv1.StorageCluster) (reconcile.Result, error) {
+	for _, manager := range nfsResourceManagers {
+		if result, err := manager.ensureCreated(r, ctx, instance); err != nil {
+			return result, err
+		}
+	}
+	return reconcile.Result{}, nil
+}
@@ -0,0 +1,26 @@
+package storagecluster
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	api "github.com/red-hat-storage/ocs-operator/api/v1"
+)
+
+func TestNFSResourceManagersRunCleanly(t *testing.T) {
+	cp := &Platform{platform: allPlatforms[0]}
+	_, reconciler, cr, _ := initStorageClusterResourceCreateUpdateTestWithPlatform(t, cp, nil, nil)
+	cr.Spec.NFS = &api.NFSSpec{Enable: true}
+	ctx := context.Background()
+	createRookCephMonSecret(t, ctx, reconciler, cr.Namespace)
+
+	_, err := reconciler.ReconcileNFS(ctx, cr)
+	assert.NoError(t, err)
+
+	for _, manager := range nfsResourceManagers {
+		_, err := manager.ensureDeleted(&reconciler, ctx, cr)
+		assert.NoError(t, err)
+	}
+}
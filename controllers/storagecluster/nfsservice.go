@@ -3,6 +3,7 @@ package storagecluster
 import (
 	"context"
 	"fmt"
+	"reflect"
 
 	ocsv1 "github.com/red-hat-storage/ocs-operator/api/v1"
 	v1 "k8s.io/api/core/v1"
@@ -42,10 +43,67 @@ func (r *StorageClusterReconciler) newNFSService(initData *ocsv1.StorageCluster)
 		},
 	}
 
-	if initData.Spec.Network != nil && initData.Spec.Network.HostNetwork {
+	headless := initData.Spec.Network != nil && initData.Spec.Network.HostNetwork
+	if headless {
 		obj.Spec.ClusterIP = v1.ClusterIPNone
 	}
 
+	// Default to ClusterIP so Status.NFS.ServiceType reports the Service's
+	// real type even when Spec.NFS.ServiceType is left unset.
+	obj.Spec.Type = v1.ServiceTypeClusterIP
+
+	if nfs := initData.Spec.NFS; nfs != nil {
+		// A headless Service (ClusterIP: None) can't carry Type or
+		// LoadBalancerIP/LoadBalancerSourceRanges - the API server rejects
+		// them - so leave it exactly ClusterIP-None regardless of what
+		// Spec.NFS requests here.
+		if !headless {
+			if nfs.ServiceType != "" {
+				obj.Spec.Type = v1.ServiceType(nfs.ServiceType)
+			}
+			if nfs.LoadBalancerIP != "" {
+				obj.Spec.LoadBalancerIP = nfs.LoadBalancerIP
+			}
+			if len(nfs.LoadBalancerSourceRanges) > 0 {
+				obj.Spec.LoadBalancerSourceRanges = nfs.LoadBalancerSourceRanges
+			}
+		}
+		if len(nfs.ServiceAnnotations) > 0 {
+			if obj.Annotations == nil {
+				obj.Annotations = map[string]string{}
+			}
+			for k, v := range nfs.ServiceAnnotations {
+				obj.Annotations[k] = v
+			}
+		}
+
+		// HighAvailability.ServiceType/AnnotationOverrides take precedence
+		// over the top-level fields above when both are set, since HA mode
+		// may need to override ServiceType to fan out per-instance Services.
+		if nfs.HighAvailability != nil {
+			ha := nfs.HighAvailability
+			if !headless && ha.ServiceType != "" {
+				obj.Spec.Type = v1.ServiceType(ha.ServiceType)
+			}
+			if len(ha.AnnotationOverrides) > 0 {
+				if obj.Annotations == nil {
+					obj.Annotations = map[string]string{}
+				}
+				for k, v := range ha.AnnotationOverrides {
+					obj.Annotations[k] = v
+				}
+			}
+		}
+
+		// ExternalTrafficPolicy is only valid on NodePort/LoadBalancer
+		// Services; the API server rejects it on ClusterIP (including
+		// headless) Services, so only apply it once obj.Spec.Type has
+		// settled on one of those two types above.
+		if nfs.ExternalTrafficPolicy != "" && (obj.Spec.Type == v1.ServiceTypeNodePort || obj.Spec.Type == v1.ServiceTypeLoadBalancer) {
+			obj.Spec.ExternalTrafficPolicy = v1.ServiceExternalTrafficPolicyType(nfs.ExternalTrafficPolicy)
+		}
+	}
+
 	err := controllerutil.SetControllerReference(initData, obj, r.Scheme)
 	if err != nil {
 		r.Log.Error(err, "Unable to set Controller Reference for NFS service.", " NFSService ", klog.KRef(obj.Namespace, obj.Name))
@@ -57,9 +115,13 @@ func (r *StorageClusterReconciler) newNFSService(initData *ocsv1.StorageCluster)
 
 // ensureCreated ensures that NFS Service resources exist in the desired
 // state.
-func (obj *ocsNFSService) ensureCreated(r *StorageClusterReconciler, instance *ocsv1.StorageCluster) (reconcile.Result, error) {
-	if instance.Spec.NFS == nil || !instance.Spec.NFS.Enable {
-		return obj.ensureDeleted(r, instance)
+func (obj *ocsNFSService) ensureCreated(r *StorageClusterReconciler, ctx context.Context, instance *ocsv1.StorageCluster) (reconcile.Result, error) {
+	if err := ctx.Err(); err != nil {
+		return reconcile.Result{}, err
+	}
+
+	if instance.Spec.NFS == nil || !instance.Spec.NFS.Enable || isClusterBeingCleanedUp(instance) {
+		return obj.ensureDeleted(r, ctx, instance)
 	}
 
 	nfsService, err := r.newNFSService(instance)
@@ -68,7 +130,7 @@ func (obj *ocsNFSService) ensureCreated(r *StorageClusterReconciler, instance *o
 	}
 
 	existing := v1.Service{}
-	err = r.Client.Get(context.TODO(), types.NamespacedName{Name: nfsService.Name, Namespace: nfsService.Namespace}, &existing)
+	err = r.Client.Get(ctx, types.NamespacedName{Name: nfsService.Name, Namespace: nfsService.Namespace}, &existing)
 	switch {
 	case err == nil:
 		if existing.DeletionTimestamp != nil {
@@ -76,38 +138,86 @@ func (obj *ocsNFSService) ensureCreated(r *StorageClusterReconciler, instance *o
 			return reconcile.Result{}, fmt.Errorf("failed to restore initialization object %s because it is marked for deletion", existing.Name)
 		}
 
-		r.Log.Info("Restoring original NFS service.", "NFSService", klog.KRef(nfsService.Namespace, nfsService.Name))
-		existing.ObjectMeta.OwnerReferences = nfsService.ObjectMeta.OwnerReferences
-		existing.Spec.Ports = nfsService.Spec.Ports
-		existing.Spec.Selector = nfsService.Spec.Selector
-		existing.Spec.SessionAffinity = nfsService.Spec.SessionAffinity
+		drifted := false
+		if existing.Spec.SessionAffinity != nfsService.Spec.SessionAffinity {
+			existing.Spec.SessionAffinity = nfsService.Spec.SessionAffinity
+			drifted = true
+		}
+		if mergeNFSServiceOwnedFields(&existing.Spec, nfsService.Spec) {
+			r.Log.Info("Restoring drifted fields on NFS service.", "NFSService", klog.KRef(nfsService.Namespace, nfsService.Name))
+			r.recorder.Event(instance, v1.EventTypeNormal, "NFSServiceDriftCorrected", fmt.Sprintf("Reconciled OCS-owned fields on Service %s", nfsService.Name))
+			drifted = true
+		}
+		if len(nfsService.Annotations) > 0 {
+			for k, v := range nfsService.Annotations {
+				if existing.Annotations[k] != v {
+					if existing.Annotations == nil {
+						existing.Annotations = map[string]string{}
+					}
+					existing.Annotations[k] = v
+					drifted = true
+				}
+			}
+		}
+		if !reflect.DeepEqual(existing.ObjectMeta.OwnerReferences, nfsService.ObjectMeta.OwnerReferences) {
+			existing.ObjectMeta.OwnerReferences = nfsService.ObjectMeta.OwnerReferences
+			drifted = true
+		}
 
-		err = r.Client.Update(context.TODO(), &existing)
-		if err != nil {
-			r.Log.Error(err, "Unable to update NFS service.", "NFSService", klog.KRef(nfsService.Namespace, nfsService.Name))
-			return reconcile.Result{}, err
+		// Only Update when something actually drifted, to avoid a no-op
+		// Update (and the resourceVersion churn that comes with it) on
+		// every reconcile, matching the CSI StorageClass's drift-gated path.
+		if drifted {
+			err = r.Client.Update(ctx, &existing)
+			if err != nil {
+				r.Log.Error(err, "Unable to update NFS service.", "NFSService", klog.KRef(nfsService.Namespace, nfsService.Name))
+				return reconcile.Result{}, err
+			}
 		}
+		updateNFSServiceStatus(instance, &existing)
 	case errors.IsNotFound(err):
 		r.Log.Info("Creating NFS service.", "NFSService", klog.KRef(nfsService.Namespace, nfsService.Name))
-		err = r.Client.Create(context.TODO(), nfsService)
+		err = r.Client.Create(ctx, nfsService)
 		if err != nil {
 			r.Log.Error(err, "Unable to create NFS service.", "NFSService", klog.KRef(nfsService.Namespace, nfsService.Namespace))
 			return reconcile.Result{}, err
 		}
+		updateNFSServiceStatus(instance, nfsService)
 	}
 
 	return reconcile.Result{}, nil
 }
 
+// updateNFSServiceStatus surfaces the Service type and, once assigned, its
+// external IP/hostname onto StorageCluster.Status so users consuming
+// LoadBalancer/NodePort NFS exports don't have to look up the Service
+// directly.
+func updateNFSServiceStatus(instance *ocsv1.StorageCluster, svc *v1.Service) {
+	addresses := make([]string, 0, len(svc.Status.LoadBalancer.Ingress))
+	for _, ingress := range svc.Status.LoadBalancer.Ingress {
+		if ingress.IP != "" {
+			addresses = append(addresses, ingress.IP)
+		}
+		if ingress.Hostname != "" {
+			addresses = append(addresses, ingress.Hostname)
+		}
+	}
+
+	instance.Status.NFS = &ocsv1.NFSStatus{
+		ServiceType:       string(svc.Spec.Type),
+		ExternalAddresses: addresses,
+	}
+}
+
 // ensureDeleted deletes the  NFS Service owned by the StorageCluster
-func (obj *ocsNFSService) ensureDeleted(r *StorageClusterReconciler, sc *ocsv1.StorageCluster) (reconcile.Result, error) {
+func (obj *ocsNFSService) ensureDeleted(r *StorageClusterReconciler, ctx context.Context, sc *ocsv1.StorageCluster) (reconcile.Result, error) {
 	foundNFSService := &v1.Service{}
 	nfsService, err := r.newNFSService(sc)
 	if err != nil {
 		return reconcile.Result{}, err
 	}
 
-	err = r.Client.Get(context.TODO(), types.NamespacedName{Name: nfsService.Name, Namespace: sc.Namespace}, foundNFSService)
+	err = r.Client.Get(ctx, types.NamespacedName{Name: nfsService.Name, Namespace: sc.Namespace}, foundNFSService)
 	if err != nil {
 		if errors.IsNotFound(err) {
 			r.Log.Info("Uninstall: NFS Service not found.", "NFSService", klog.KRef(nfsService.Namespace, nfsService.Name))
@@ -119,14 +229,21 @@ func (obj *ocsNFSService) ensureDeleted(r *StorageClusterReconciler, sc *ocsv1.S
 
 	if nfsService.GetDeletionTimestamp().IsZero() {
 		r.Log.Info("Uninstall: Deleting NFS Service.", "NFSService", klog.KRef(foundNFSService.Namespace, foundNFSService.Name))
-		err = r.Client.Delete(context.TODO(), foundNFSService)
+		err = r.Client.Delete(ctx, foundNFSService)
 		if err != nil {
 			r.Log.Error(err, "Uninstall: Failed to delete NFS Service.", "NFSService", klog.KRef(foundNFSService.Namespace, foundNFSService.Name))
 			return reconcile.Result{}, fmt.Errorf("uninstall: Failed to delete NFS Service %v: %v", foundNFSService.Name, err)
 		}
 	}
 
-	err = r.Client.Get(context.TODO(), types.NamespacedName{Name: nfsService.Name, Namespace: sc.Namespace}, foundNFSService)
+	if isClusterBeingCleanedUp(sc) {
+		// Forced destroy: don't block tear-down waiting for the Service to
+		// disappear, since whatever is holding its finalizer (e.g. an
+		// unresponsive Ceph) may never release it.
+		return reconcile.Result{}, nil
+	}
+
+	err = r.Client.Get(ctx, types.NamespacedName{Name: nfsService.Name, Namespace: sc.Namespace}, foundNFSService)
 	if err != nil {
 		if errors.IsNotFound(err) {
 			r.Log.Info("Uninstall: NFS Service is deleted.", "NFSService", klog.KRef(nfsService.Namespace, nfsService.Name))
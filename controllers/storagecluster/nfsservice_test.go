@@ -4,9 +4,12 @@ import (
 	"context"
 	"testing"
 
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
 	"github.com/stretchr/testify/assert"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
@@ -58,7 +61,7 @@ func assertNFSSService(t *testing.T, reconciler StorageClusterReconciler, cr *ap
 		},
 	}
 	request.Name = "ocsinit-cephnfs-service"
-	err := reconciler.Client.Get(context.TODO(), request.NamespacedName, actualNfsS)
+	err := reconciler.Client.Get(context.Background(), request.NamespacedName, actualNfsS)
 	assert.NoError(t, err)
 
 	expectedAf, err := reconciler.newNFSService(cr)
@@ -69,3 +72,175 @@ func assertNFSSService(t *testing.T, reconciler StorageClusterReconciler, cr *ap
 	assert.Equal(t, expectedAf.ObjectMeta.Name, actualNfsS.ObjectMeta.Name)
 	assert.Equal(t, expectedAf.Spec, actualNfsS.Spec)
 }
+
+func TestNFSServiceServiceTypes(t *testing.T) {
+	var cases = []struct {
+		label                    string
+		serviceType              string
+		loadBalancerIP           string
+		loadBalancerSourceRanges []string
+		externalTrafficPolicy    string
+		serviceAnnotations       map[string]string
+	}{
+		{
+			label:       "ClusterIP",
+			serviceType: string(v1.ServiceTypeClusterIP),
+		},
+		{
+			label:                 "NodePort",
+			serviceType:           string(v1.ServiceTypeNodePort),
+			externalTrafficPolicy: string(v1.ServiceExternalTrafficPolicyTypeLocal),
+		},
+		{
+			label:                    "LoadBalancer",
+			serviceType:              string(v1.ServiceTypeLoadBalancer),
+			loadBalancerIP:           "10.0.0.5",
+			loadBalancerSourceRanges: []string{"10.0.0.0/8"},
+			serviceAnnotations:       map[string]string{"service.beta.kubernetes.io/metallb-allow-shared-ip": "nfs"},
+		},
+	}
+
+	cp := &Platform{platform: allPlatforms[0]}
+	for _, c := range cases {
+		t.Run(c.label, func(t *testing.T) {
+			_, reconciler, cr, _ := initStorageClusterResourceCreateUpdateTestWithPlatform(t, cp, nil, nil)
+			cr.Spec.NFS = &api.NFSSpec{
+				Enable:                   true,
+				ServiceType:              c.serviceType,
+				LoadBalancerIP:           c.loadBalancerIP,
+				LoadBalancerSourceRanges: c.loadBalancerSourceRanges,
+				ExternalTrafficPolicy:    c.externalTrafficPolicy,
+				ServiceAnnotations:       c.serviceAnnotations,
+			}
+
+			svc, err := reconciler.newNFSService(cr)
+			assert.NoError(t, err)
+			assert.Equal(t, v1.ServiceType(c.serviceType), svc.Spec.Type)
+			assert.Equal(t, c.loadBalancerIP, svc.Spec.LoadBalancerIP)
+			assert.Equal(t, c.loadBalancerSourceRanges, svc.Spec.LoadBalancerSourceRanges)
+			assert.Equal(t, v1.ServiceExternalTrafficPolicyType(c.externalTrafficPolicy), svc.Spec.ExternalTrafficPolicy)
+			for k, v := range c.serviceAnnotations {
+				assert.Equal(t, v, svc.Annotations[k])
+			}
+		})
+	}
+}
+
+func TestNFSServiceHeadlessIgnoresTypeAndLoadBalancerFields(t *testing.T) {
+	cp := &Platform{platform: allPlatforms[0]}
+	_, reconciler, cr, _ := initStorageClusterResourceCreateUpdateTestWithPlatform(t, cp, nil, nil)
+	cr.Spec.Network = &cephv1.NetworkSpec{HostNetwork: true}
+	cr.Spec.NFS = &api.NFSSpec{
+		Enable:                true,
+		ServiceType:           string(v1.ServiceTypeLoadBalancer),
+		LoadBalancerIP:        "10.0.0.5",
+		ExternalTrafficPolicy: string(v1.ServiceExternalTrafficPolicyTypeLocal),
+	}
+
+	svc, err := reconciler.newNFSService(cr)
+	assert.NoError(t, err)
+	// the headless, host-network Service can't carry Type,
+	// LoadBalancerIP, or ExternalTrafficPolicy - the API server rejects all
+	// three on a ClusterIP: None Service
+	assert.Equal(t, v1.ClusterIPNone, svc.Spec.ClusterIP)
+	assert.Equal(t, v1.ServiceTypeClusterIP, svc.Spec.Type)
+	assert.Empty(t, svc.Spec.LoadBalancerIP)
+	assert.Empty(t, svc.Spec.ExternalTrafficPolicy)
+}
+
+func TestNFSServiceExternalTrafficPolicyIgnoredOnClusterIP(t *testing.T) {
+	cp := &Platform{platform: allPlatforms[0]}
+	_, reconciler, cr, _ := initStorageClusterResourceCreateUpdateTestWithPlatform(t, cp, nil, nil)
+	cr.Spec.NFS = &api.NFSSpec{
+		Enable:                true,
+		ServiceType:           string(v1.ServiceTypeClusterIP),
+		ExternalTrafficPolicy: string(v1.ServiceExternalTrafficPolicyTypeLocal),
+	}
+
+	svc, err := reconciler.newNFSService(cr)
+	assert.NoError(t, err)
+	// ExternalTrafficPolicy is only valid on NodePort/LoadBalancer Services;
+	// the API server rejects it on ClusterIP
+	assert.Empty(t, svc.Spec.ExternalTrafficPolicy)
+}
+
+func TestNFSServiceEnsureCreatedSkipsNoOpUpdate(t *testing.T) {
+	cp := &Platform{platform: allPlatforms[0]}
+	_, reconciler, cr, _ := initStorageClusterResourceCreateUpdateTestWithPlatform(t, cp, nil, nil)
+	cr.Spec.NFS = &api.NFSSpec{Enable: true}
+
+	obj := &ocsNFSService{}
+	ctx := context.Background()
+	_, err := obj.ensureCreated(&reconciler, ctx, cr)
+	assert.NoError(t, err)
+
+	before := &v1.Service{}
+	err = reconciler.Client.Get(ctx, types.NamespacedName{Name: generateNameForNFSService(cr), Namespace: cr.Namespace}, before)
+	assert.NoError(t, err)
+
+	// nothing in Spec.NFS changed, so this reconcile must not write the
+	// Service again and churn its resourceVersion
+	_, err = obj.ensureCreated(&reconciler, ctx, cr)
+	assert.NoError(t, err)
+
+	after := &v1.Service{}
+	err = reconciler.Client.Get(ctx, types.NamespacedName{Name: generateNameForNFSService(cr), Namespace: cr.Namespace}, after)
+	assert.NoError(t, err)
+	assert.Equal(t, before.ResourceVersion, after.ResourceVersion)
+}
+
+func TestNFSServiceEnsureCreatedReconcilesServiceTypeWithoutRecreate(t *testing.T) {
+	cp := &Platform{platform: allPlatforms[0]}
+	_, reconciler, cr, _ := initStorageClusterResourceCreateUpdateTestWithPlatform(t, cp, nil, nil)
+	cr.Spec.NFS = &api.NFSSpec{Enable: true}
+
+	obj := &ocsNFSService{}
+	ctx := context.Background()
+	_, err := obj.ensureCreated(&reconciler, ctx, cr)
+	assert.NoError(t, err)
+	assert.Equal(t, string(v1.ServiceTypeClusterIP), cr.Status.NFS.ServiceType)
+
+	cr.Spec.NFS.ServiceType = string(v1.ServiceTypeLoadBalancer)
+	cr.Spec.NFS.LoadBalancerIP = "10.0.0.9"
+	_, err = obj.ensureCreated(&reconciler, ctx, cr)
+	assert.NoError(t, err)
+
+	existing := &v1.Service{}
+	err = reconciler.Client.Get(context.TODO(), types.NamespacedName{Name: generateNameForNFSService(cr), Namespace: cr.Namespace}, existing)
+	assert.NoError(t, err)
+	assert.Equal(t, v1.ServiceTypeLoadBalancer, existing.Spec.Type)
+	assert.Equal(t, "10.0.0.9", existing.Spec.LoadBalancerIP)
+	assert.Equal(t, string(v1.ServiceTypeLoadBalancer), cr.Status.NFS.ServiceType)
+}
+
+func TestNFSServiceHonorsCleanupPolicy(t *testing.T) {
+	cp := &Platform{platform: allPlatforms[0]}
+	_, reconciler, cr, _ := initStorageClusterResourceCreateUpdateTestWithPlatform(t, cp, nil, nil)
+	cr.Spec.NFS = &api.NFSSpec{Enable: true}
+
+	obj := &ocsNFSService{}
+	ctx := context.Background()
+	_, err := obj.ensureCreated(&reconciler, ctx, cr)
+	assert.NoError(t, err)
+
+	existing := &v1.Service{}
+	err = reconciler.Client.Get(context.TODO(), types.NamespacedName{Name: generateNameForNFSService(cr), Namespace: cr.Namespace}, existing)
+	assert.NoError(t, err)
+
+	if cr.Annotations == nil {
+		cr.Annotations = map[string]string{}
+	}
+	cr.Annotations[nfsCleanupPolicyAnnotation] = nfsCleanupPolicyConfirmation
+
+	_, err = obj.ensureCreated(&reconciler, ctx, cr)
+	assert.NoError(t, err)
+
+	err = reconciler.Client.Get(context.TODO(), types.NamespacedName{Name: generateNameForNFSService(cr), Namespace: cr.Namespace}, existing)
+	assert.True(t, errors.IsNotFound(err))
+
+	// a subsequent reconcile must not recreate the Service
+	_, err = obj.ensureCreated(&reconciler, ctx, cr)
+	assert.NoError(t, err)
+	err = reconciler.Client.Get(context.TODO(), types.NamespacedName{Name: generateNameForNFSService(cr), Namespace: cr.Namespace}, existing)
+	assert.True(t, errors.IsNotFound(err))
+}
@@ -0,0 +1,115 @@
+package storagecluster
+
+import (
+	"reflect"
+
+	ocsv1 "github.com/red-hat-storage/ocs-operator/api/v1"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	v1 "k8s.io/api/core/v1"
+)
+
+// mergeCephNFSOwnedFields copies only the CephNFS fields OCS actually owns
+// (Placement, Resources, PriorityClassName, and Active when HA is off) from
+// `desired` onto `existing`, leaving admin-set extras such as
+// `Server.LogLevel` or `Server.HostNetwork` untouched. It returns true if
+// any owned field had drifted from the desired state.
+func mergeCephNFSOwnedFields(instance *ocsv1.StorageCluster, existing *cephv1.NFSGaneshaSpec, desired cephv1.NFSGaneshaSpec) bool {
+	drifted := false
+
+	if !reflect.DeepEqual(existing.Server.Placement, desired.Server.Placement) {
+		existing.Server.Placement = desired.Server.Placement
+		drifted = true
+	}
+	if !reflect.DeepEqual(existing.Server.Resources, desired.Server.Resources) {
+		existing.Server.Resources = desired.Server.Resources
+		drifted = true
+	}
+	if existing.Server.PriorityClassName != desired.Server.PriorityClassName {
+		existing.Server.PriorityClassName = desired.Server.PriorityClassName
+		drifted = true
+	}
+
+	// Active is only OCS-owned when HighAvailability scale-out isn't
+	// requested; once HA is enabled this merge leaves the existing CephNFS's
+	// active server count as it is, and no other reconciler here updates it.
+	if instance.Spec.NFS == nil || instance.Spec.NFS.HighAvailability == nil {
+		if existing.Server.Active != desired.Server.Active {
+			existing.Server.Active = desired.Server.Active
+			drifted = true
+		}
+	}
+
+	return drifted
+}
+
+// mergeCephNFSBlockPoolOwnedFields copies only the CephBlockPool fields OCS
+// owns (FailureDomain, Replicated, EnableRBDStats) from `desired` onto
+// `existing`, preserving any extra `PoolSpec.Parameters` an admin set
+// out-of-band. It returns true if any owned field had drifted.
+func mergeCephNFSBlockPoolOwnedFields(existing *cephv1.NamedBlockPoolSpec, desired cephv1.NamedBlockPoolSpec) bool {
+	drifted := false
+
+	if existing.PoolSpec.FailureDomain != desired.PoolSpec.FailureDomain {
+		existing.PoolSpec.FailureDomain = desired.PoolSpec.FailureDomain
+		drifted = true
+	}
+	if !reflect.DeepEqual(existing.PoolSpec.Replicated, desired.PoolSpec.Replicated) {
+		existing.PoolSpec.Replicated = desired.PoolSpec.Replicated
+		drifted = true
+	}
+	if existing.PoolSpec.EnableRBDStats != desired.PoolSpec.EnableRBDStats {
+		existing.PoolSpec.EnableRBDStats = desired.PoolSpec.EnableRBDStats
+		drifted = true
+	}
+
+	return drifted
+}
+
+// mergeNFSServiceOwnedFields copies only the Service fields OCS owns
+// (Selector, the canonical `nfs` port, and the Type/LoadBalancer fields
+// driven by Spec.NFS) from `desired` onto `existing`, preserving any extra
+// ports or admin-set annotations. It returns true if any owned field had
+// drifted.
+func mergeNFSServiceOwnedFields(existing *v1.ServiceSpec, desired v1.ServiceSpec) bool {
+	drifted := false
+
+	if !reflect.DeepEqual(existing.Selector, desired.Selector) {
+		existing.Selector = desired.Selector
+		drifted = true
+	}
+
+	found := false
+	for i := range existing.Ports {
+		if existing.Ports[i].Name == "nfs" {
+			found = true
+			if !reflect.DeepEqual(existing.Ports[i], desired.Ports[0]) {
+				existing.Ports[i] = desired.Ports[0]
+				drifted = true
+			}
+			break
+		}
+	}
+	if !found {
+		existing.Ports = append(existing.Ports, desired.Ports[0])
+		drifted = true
+	}
+
+	if existing.Type != desired.Type {
+		existing.Type = desired.Type
+		drifted = true
+	}
+	if existing.LoadBalancerIP != desired.LoadBalancerIP {
+		existing.LoadBalancerIP = desired.LoadBalancerIP
+		drifted = true
+	}
+	if !reflect.DeepEqual(existing.LoadBalancerSourceRanges, desired.LoadBalancerSourceRanges) {
+		existing.LoadBalancerSourceRanges = desired.LoadBalancerSourceRanges
+		drifted = true
+	}
+	if existing.ExternalTrafficPolicy != desired.ExternalTrafficPolicy {
+		existing.ExternalTrafficPolicy = desired.ExternalTrafficPolicy
+		drifted = true
+	}
+
+	return drifted
+}
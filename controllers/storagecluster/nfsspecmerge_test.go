@@ -0,0 +1,93 @@
+package storagecluster
+
+import (
+	"testing"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+
+	api "github.com/red-hat-storage/ocs-operator/api/v1"
+)
+
+func TestMergeCephNFSOwnedFieldsPreservesAdminExtras(t *testing.T) {
+	cr := &api.StorageCluster{Spec: api.StorageClusterSpec{NFS: &api.NFSSpec{Enable: true}}}
+
+	existing := cephv1.NFSGaneshaSpec{
+		Server: cephv1.GaneshaServerSpec{
+			Active:            1,
+			PriorityClassName: "old-priority",
+			LogLevel:          "DEBUG",
+			HostNetwork:       true,
+		},
+	}
+	desired := cephv1.NFSGaneshaSpec{
+		Server: cephv1.GaneshaServerSpec{
+			Active:            1,
+			PriorityClassName: "new-priority",
+		},
+	}
+
+	drifted := mergeCephNFSOwnedFields(cr, &existing, desired)
+	assert.True(t, drifted)
+	assert.Equal(t, "new-priority", existing.Server.PriorityClassName)
+	// admin-set extras are untouched by the merge
+	assert.Equal(t, "DEBUG", existing.Server.LogLevel)
+	assert.True(t, existing.Server.HostNetwork)
+}
+
+func TestMergeCephNFSOwnedFieldsSkipsActiveWhenHAEnabled(t *testing.T) {
+	cr := &api.StorageCluster{Spec: api.StorageClusterSpec{NFS: &api.NFSSpec{
+		Enable:           true,
+		HighAvailability: &api.NFSHighAvailabilitySpec{ActiveServers: 3},
+	}}}
+
+	existing := cephv1.NFSGaneshaSpec{Server: cephv1.GaneshaServerSpec{Active: 2}}
+	desired := cephv1.NFSGaneshaSpec{Server: cephv1.GaneshaServerSpec{Active: 1}}
+
+	mergeCephNFSOwnedFields(cr, &existing, desired)
+	// Active is left as it is once HA is enabled
+	assert.Equal(t, 2, existing.Server.Active)
+}
+
+func TestMergeNFSServiceOwnedFieldsPreservesExtraPorts(t *testing.T) {
+	existing := v1.ServiceSpec{
+		Ports: []v1.ServicePort{
+			{Name: "nfs", Port: 2049},
+			{Name: "metrics", Port: 9587},
+		},
+		Selector: map[string]string{"stale": "true"},
+	}
+	desired := v1.ServiceSpec{
+		Ports:    []v1.ServicePort{{Name: "nfs", Port: 2049, Protocol: v1.ProtocolTCP}},
+		Selector: map[string]string{"app": "rook-ceph-nfs"},
+	}
+
+	drifted := mergeNFSServiceOwnedFields(&existing, desired)
+	assert.True(t, drifted)
+	assert.Equal(t, map[string]string{"app": "rook-ceph-nfs"}, existing.Selector)
+	assert.Len(t, existing.Ports, 2)
+	assert.Equal(t, v1.ProtocolTCP, existing.Ports[0].Protocol)
+	// the out-of-band metrics port survives the reconcile
+	assert.Equal(t, "metrics", existing.Ports[1].Name)
+}
+
+func TestMergeNFSServiceOwnedFieldsRestoresServiceType(t *testing.T) {
+	existing := v1.ServiceSpec{
+		Ports:                    []v1.ServicePort{{Name: "nfs", Port: 2049}},
+		Type:                     v1.ServiceTypeLoadBalancer,
+		LoadBalancerIP:           "10.0.0.1",
+		LoadBalancerSourceRanges: []string{"10.0.0.0/24"},
+	}
+	desired := v1.ServiceSpec{
+		Ports:          []v1.ServicePort{{Name: "nfs", Port: 2049}},
+		Type:           v1.ServiceTypeClusterIP,
+		LoadBalancerIP: "",
+	}
+
+	drifted := mergeNFSServiceOwnedFields(&existing, desired)
+	assert.True(t, drifted)
+	assert.Equal(t, v1.ServiceTypeClusterIP, existing.Type)
+	assert.Empty(t, existing.LoadBalancerIP)
+	assert.Empty(t, existing.LoadBalancerSourceRanges)
+}